@@ -0,0 +1,123 @@
+package yologo
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// anchorIOU IoU of two boxes of given sizes which share the same center
+func anchorIOU(w1, h1, w2, h2 float32) float32 {
+	inter := minF32(w1, w2) * minF32(h1, h2)
+	union := w1*h1 + w2*h2 - inter
+	if union <= 0 {
+		return 0
+	}
+	return inter / union
+}
+
+// KMeansAnchors Clusters sizes of boxes into k anchors via k-means with (1 - IoU) distance
+/*
+	sizes - widths and heights of boxes: [w_0, h_0, w_1, h_1, ...] (usually in pixels of network's input).
+	Initial centroids are chosen via k-means++ with given seed; clustering stops when assignment of boxes doesn't change
+	or after given number of iterations.
+	Returns anchors sorted by area in the same format (ready for 'anchors' parameter of YOLO layer) and average IoU
+	between every box and its best anchor.
+*/
+func KMeansAnchors(sizes []float32, k, iterations int, seed int64) ([]float32, float32, error) {
+	if len(sizes)%2 != 0 {
+		return nil, 0, fmt.Errorf("Number of values in sizes of boxes should be even, but got %d", len(sizes))
+	}
+	n := len(sizes) / 2
+	if k < 1 || k > n {
+		return nil, 0, fmt.Errorf("Number of anchors should be in range [1; %d], but got %d", n, k)
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	// k-means++ initialization: next centroid is chosen with probability proportional to distance to the nearest one
+	centroids := make([]float32, 0, 2*k)
+	first := rng.Intn(n)
+	centroids = append(centroids, sizes[2*first], sizes[2*first+1])
+	distances := make([]float32, n)
+	for len(centroids) < 2*k {
+		total := float32(0.0)
+		for i := 0; i < n; i++ {
+			distances[i] = 1
+			for c := 0; c < len(centroids); c += 2 {
+				distances[i] = minF32(distances[i], 1-anchorIOU(sizes[2*i], sizes[2*i+1], centroids[c], centroids[c+1]))
+			}
+			total += distances[i]
+		}
+		next := 0
+		if total > 0 {
+			target := rng.Float32() * total
+			for next = 0; next < n-1; next++ {
+				target -= distances[next]
+				if target <= 0 {
+					break
+				}
+			}
+		} else {
+			next = rng.Intn(n)
+		}
+		centroids = append(centroids, sizes[2*next], sizes[2*next+1])
+	}
+
+	assignments := make([]int, n)
+	for i := range assignments {
+		assignments[i] = -1
+	}
+	for iter := 0; iter < iterations; iter++ {
+		changed := false
+		for i := 0; i < n; i++ {
+			best, bestIOU := 0, float32(-1.0)
+			for c := 0; c < k; c++ {
+				if iou := anchorIOU(sizes[2*i], sizes[2*i+1], centroids[2*c], centroids[2*c+1]); iou > bestIOU {
+					best, bestIOU = c, iou
+				}
+			}
+			if assignments[i] != best {
+				assignments[i] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+		sums := make([]float32, 2*k)
+		counts := make([]int, k)
+		for i := 0; i < n; i++ {
+			sums[2*assignments[i]] += sizes[2*i]
+			sums[2*assignments[i]+1] += sizes[2*i+1]
+			counts[assignments[i]]++
+		}
+		for c := 0; c < k; c++ {
+			// Empty cluster keeps its centroid
+			if counts[c] > 0 {
+				centroids[2*c] = sums[2*c] / float32(counts[c])
+				centroids[2*c+1] = sums[2*c+1] / float32(counts[c])
+			}
+		}
+	}
+
+	order := make([]int, k)
+	for c := range order {
+		order[c] = c
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return centroids[2*order[i]]*centroids[2*order[i]+1] < centroids[2*order[j]]*centroids[2*order[j]+1]
+	})
+	anchors := make([]float32, 0, 2*k)
+	for _, c := range order {
+		anchors = append(anchors, centroids[2*c], centroids[2*c+1])
+	}
+	avgIOU := float32(0.0)
+	for i := 0; i < n; i++ {
+		bestIOU := float32(0.0)
+		for c := 0; c < k; c++ {
+			bestIOU = maxF32(bestIOU, anchorIOU(sizes[2*i], sizes[2*i+1], anchors[2*c], anchors[2*c+1]))
+		}
+		avgIOU += bestIOU
+	}
+	return anchors, avgIOU / float32(n), nil
+}
@@ -0,0 +1,53 @@
+package yologo
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKMeansAnchors(t *testing.T) {
+	// Three clusters of sizes (listed not in order of area) with small noise around centers
+	centers := []float32{120, 90, 10, 14, 40, 60}
+	rng := rand.New(rand.NewSource(1))
+	sizes := []float32{}
+	for i := 0; i < 50; i++ {
+		for c := 0; c < len(centers); c += 2 {
+			sizes = append(sizes, centers[c]*(0.95+0.1*rng.Float32()), centers[c+1]*(0.95+0.1*rng.Float32()))
+		}
+	}
+
+	anchors, avgIOU, err := KMeansAnchors(sizes, 3, 100, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []float32{10, 14, 40, 60, 120, 90}
+	if !assert.Len(t, anchors, len(expected)) {
+		return
+	}
+	for i := range expected {
+		assert.InDelta(t, expected[i], anchors[i], 0.03*float64(expected[i]), "Anchor value #%d", i)
+	}
+	assert.True(t, avgIOU > 0.9, "Average IoU should be high for well separated clusters, got %f", avgIOU)
+
+	// The same seed gives the same anchors
+	again, againIOU, err := KMeansAnchors(sizes, 3, 100, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, anchors, again)
+	assert.Equal(t, avgIOU, againIOU)
+
+	// Single anchor for every box
+	anchors, _, err = KMeansAnchors(sizes[:2], 1, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, sizes[:2], anchors)
+
+	_, _, err = KMeansAnchors(sizes[:3], 1, 10, 1)
+	assert.Error(t, err, "Odd number of values")
+	_, _, err = KMeansAnchors(sizes[:4], 3, 10, 1)
+	assert.Error(t, err, "More anchors than boxes")
+}
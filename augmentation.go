@@ -0,0 +1,61 @@
+package yologo
+
+import (
+	"math/rand"
+)
+
+// Augmentation Random transformations of training images (see WithAugmentation())
+type Augmentation struct {
+	// Flip Probability of horizontal flip of image (annotations are flipped accordingly)
+	Flip float32
+	// Exposure Maximal relative change of brightness: pixels are multiplied by random factor from [1 - Exposure; 1 + Exposure]
+	Exposure float32
+	// Saturation Maximal relative change of saturation: distance of pixels to their gray level is multiplied
+	// by random factor from [1 - Saturation; 1 + Saturation]
+	Saturation float32
+}
+
+// apply Returns augmented copies of image (see Image2Float32()) and of its darknet annotations (see ParseLabels())
+/*
+	Random values are taken from rng in fixed order, so result depends on state of rng only.
+*/
+func (aug *Augmentation) apply(rng *rand.Rand, imgf32 []float32, width, height int, target []float32) ([]float32, []float32) {
+	flip := rng.Float32() < aug.Flip
+	exposure := 1 + aug.Exposure*(2*rng.Float32()-1)
+	saturation := 1 + aug.Saturation*(2*rng.Float32()-1)
+
+	planeSize := width * height
+	img := make([]float32, len(imgf32))
+	for i := 0; i < planeSize; i++ {
+		src := i
+		if flip {
+			row, col := i/width, i%width
+			src = row*width + width - 1 - col
+		}
+		r, g, b := imgf32[src], imgf32[src+planeSize], imgf32[src+2*planeSize]
+		gray := 0.299*r + 0.587*g + 0.114*b
+		img[i] = clampUnit((gray + saturation*(r-gray)) * exposure)
+		img[i+planeSize] = clampUnit((gray + saturation*(g-gray)) * exposure)
+		img[i+2*planeSize] = clampUnit((gray + saturation*(b-gray)) * exposure)
+	}
+
+	labels := append([]float32{}, target...)
+	if flip {
+		// Every annotation is [class, x, y, w, h]
+		for i := 1; i < len(labels); i += 5 {
+			labels[i] = 1 - labels[i]
+		}
+	}
+	return img, labels
+}
+
+// clampUnit Clamps value to range [0; 1]
+func clampUnit(v float32) float32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
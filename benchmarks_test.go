@@ -0,0 +1,260 @@
+package yologo
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+var benchCOCOClasses = make([]string, 80)
+
+// benchConvConfiguration Darknet configuration of network with single convolution layer
+func benchConvConfiguration(size, channels, filters, kernel, stride int) string {
+	return fmt.Sprintf(`[net]
+width=%[1]d
+height=%[1]d
+channels=%[2]d
+
+[convolutional]
+batch_normalize=1
+filters=%[3]d
+size=%[4]d
+stride=%[5]d
+pad=1
+activation=leaky
+`, size, channels, filters, kernel, stride)
+}
+
+// benchNetwork Prepares network for given configuration with synthetic weights
+func benchNetwork(b *testing.B, cfg string, size, channels int) (*YOLOv3, *gorgonia.ExprGraph, *gorgonia.Node) {
+	dir, err := ioutil.TempDir("", "yologo_bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfgFile := filepath.Join(dir, "bench.cfg")
+	weightsFile := filepath.Join(dir, "bench.weights")
+	if err := ioutil.WriteFile(cfgFile, []byte(cfg), 0644); err != nil {
+		b.Fatal(err)
+	}
+	return benchNetworkFromFile(b, cfgFile, weightsFile, size, channels)
+}
+
+func benchNetworkFromFile(b *testing.B, cfgFile, weightsFile string, size, channels int) (*YOLOv3, *gorgonia.ExprGraph, *gorgonia.Node) {
+	if err := WriteSyntheticWeights(cfgFile, weightsFile, 1); err != nil {
+		b.Fatal(err)
+	}
+	g := gorgonia.NewGraph()
+	input := gorgonia.NewTensor(g, tensor.Float32, 4, gorgonia.WithShape(1, channels, size, size), gorgonia.WithName("input"))
+	net, err := NewYoloV3(g, input, len(benchCOCOClasses), 3, 0.1, cfgFile, weightsFile)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := gorgonia.Let(input, benchTensor(1, channels, size, size)); err != nil {
+		b.Fatal(err)
+	}
+	return net, g, input
+}
+
+// benchTensor Tensor filled with uniform random values from [0; 1)
+func benchTensor(shape ...int) tensor.Tensor {
+	rng := rand.New(rand.NewSource(1))
+	data := make([]float32, tensor.Shape(shape).TotalSize())
+	for i := range data {
+		data[i] = rng.Float32()
+	}
+	return tensor.New(tensor.WithShape(shape...), tensor.Of(tensor.Float32), tensor.WithBacking(data))
+}
+
+func benchRunGraph(b *testing.B, g *gorgonia.ExprGraph) {
+	tm := gorgonia.NewTapeMachine(g)
+	defer tm.Close()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := tm.RunAll(); err != nil {
+			b.Fatal(err)
+		}
+		tm.Reset()
+	}
+}
+
+func BenchmarkConv2d(b *testing.B) {
+	cases := []struct {
+		size, channels, filters, kernel, stride int
+	}{
+		{416, 3, 16, 3, 1},
+		{104, 64, 128, 3, 1},
+		{52, 256, 128, 1, 1},
+		{52, 128, 256, 3, 1},
+		{26, 256, 512, 3, 2},
+		{13, 512, 1024, 3, 1},
+	}
+	for _, c := range cases {
+		name := fmt.Sprintf("%dx%d_%dto%d_%dpx_stride%d", c.kernel, c.kernel, c.channels, c.filters, c.size, c.stride)
+		b.Run(name, func(b *testing.B) {
+			_, g, _ := benchNetwork(b, benchConvConfiguration(c.size, c.channels, c.filters, c.kernel, c.stride), c.size, c.channels)
+			benchRunGraph(b, g)
+		})
+	}
+}
+
+func BenchmarkUpsample2D(b *testing.B) {
+	shapes := [][]int{
+		{1, 256, 13, 13},
+		{1, 128, 26, 26},
+	}
+	for _, shp := range shapes {
+		b.Run(fmt.Sprintf("%dx%dx%d", shp[1], shp[2], shp[3]), func(b *testing.B) {
+			g := gorgonia.NewGraph()
+			input := gorgonia.NewTensor(g, tensor.Float32, 4, gorgonia.WithShape(shp...), gorgonia.WithName("input"))
+			if _, err := Upsample2D(input, 2); err != nil {
+				b.Fatal(err)
+			}
+			if err := gorgonia.Let(input, benchTensor(shp...)); err != nil {
+				b.Fatal(err)
+			}
+			benchRunGraph(b, g)
+		})
+	}
+}
+
+func BenchmarkYoloOp(b *testing.B) {
+	anchors := []float32{10, 13, 16, 30, 33, 23, 30, 61, 62, 45, 59, 119, 116, 90, 156, 198, 373, 326}
+	heads := []struct {
+		grid  int
+		masks []int
+	}{
+		{13, []int{6, 7, 8}},
+		{26, []int{3, 4, 5}},
+		{52, []int{0, 1, 2}},
+	}
+	for _, head := range heads {
+		b.Run(fmt.Sprintf("grid%d", head.grid), func(b *testing.B) {
+			op := newYoloOp(anchors, head.masks, 416, head.grid, 80, 0.7)
+			input := benchTensor(1, 255, head.grid, head.grid)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// Operation modifies its input in place
+				b.StopTimer()
+				in := input.Clone().(tensor.Tensor)
+				b.StartTimer()
+				if _, err := op.Do(in); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkProcessOutput(b *testing.B) {
+	dir, err := ioutil.TempDir("", "yologo_bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	net, g, _ := benchNetworkFromFile(b, "test_network_data/yolov3-tiny.cfg", filepath.Join(dir, "bench.weights"), 416, 3)
+	tm := gorgonia.NewTapeMachine(g)
+	defer tm.Close()
+	if err := tm.RunAll(); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := net.ProcessOutput(benchCOCOClasses, 0.5, 0.3); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchCandidates Detections of YOLOv3-like output (3 heads, 3 anchors per cell) for score threshold 0.001
+func benchCandidates(classesNum int) Detections {
+	rng := rand.New(rand.NewSource(1))
+	anchors := []float32{10, 13, 16, 30, 33, 23, 30, 61, 62, 45, 59, 119, 116, 90, 156, 198, 373, 326}
+	classes := make([]string, classesNum)
+	data := []float32{}
+	for head, grid := range []int{52, 26, 13} {
+		stride := float32(416 / grid)
+		for cell := 0; cell < grid*grid; cell++ {
+			for a := 0; a < 3; a++ {
+				box := make([]float32, 5+classesNum)
+				box[0] = (float32(cell%grid) + rng.Float32()) * stride
+				box[1] = (float32(cell/grid) + rng.Float32()) * stride
+				box[2] = anchors[(head*3+a)*2] * (0.5 + rng.Float32())
+				box[3] = anchors[(head*3+a)*2+1] * (0.5 + rng.Float32())
+				box[4] = rng.Float32()
+				box[5+rng.Intn(classesNum)] = rng.Float32()
+				data = append(data, box...)
+			}
+		}
+	}
+	dets, boxes := prepareDetections(data, 0.001, 416, classes, nil, nil)
+	for i := range dets {
+		dets[i].rect = boxes[i].rectangle(416, 416)
+	}
+	return dets
+}
+
+func BenchmarkNMS(b *testing.B) {
+	for _, classesNum := range []int{1, 80} {
+		dets := benchCandidates(classesNum)
+		b.Run(fmt.Sprintf("Greedy/classes%d_boxes%d", classesNum, len(dets)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NonMaxSuppression(dets, 0.45)
+			}
+		})
+		b.Run(fmt.Sprintf("Grid/classes%d_boxes%d", classesNum, len(dets)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				NonMaxSuppression(dets, 0.45, WithGridNMS())
+			}
+		})
+	}
+}
+
+func BenchmarkPreprocessing(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewRGBA(image.Rect(0, 0, 1280, 720))
+	for y := 0; y < 720; y++ {
+		for x := 0; x < 1280; x++ {
+			img.Set(x, y, color.RGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 255})
+		}
+	}
+	b.Run("Bilinear", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := Image2Float32(scaleImage(img, 416, 416)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("AverageColor", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := Image2Float32(resizeImage(img, 416, 416)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("DecodeJPEG", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := GetFloat32Image("test_network_data/dog_416x416.jpg", 416, 416); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkTinyYOLOv3(b *testing.B) {
+	dir, err := ioutil.TempDir("", "yologo_bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	_, g, _ := benchNetworkFromFile(b, "test_network_data/yolov3-tiny.cfg", filepath.Join(dir, "bench.weights"), 416, 3)
+	benchRunGraph(b, g)
+}
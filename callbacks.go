@@ -0,0 +1,155 @@
+package yologo
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrStopTraining Error which callback returns to stop training gracefully (TrainFolder() returns nil then)
+var ErrStopTraining = errors.New("Training has been stopped by callback")
+
+// IterationInfo State of training after single step
+type IterationInfo struct {
+	// Iteration Number of step since start of training (starting from 0)
+	Iteration int
+	// Epoch Number of epoch (starting from 0)
+	Epoch int
+	// Image Name of trained image (see ParseLabeledFolder())
+	Image string
+	// Cost Value of cost function
+	Cost float32
+	// Losses Components of cost function
+	Losses Losses
+	// LearningRate Learning rate of optimizer step
+	LearningRate float64
+	// StepTime Duration of forward and backward passes and optimizer step
+	StepTime time.Duration
+	// WaitTime Time spent waiting for image from data loader before step
+	WaitTime time.Duration
+	// Elapsed Time since start of training
+	Elapsed time.Duration
+}
+
+// EpochInfo Summary of finished epoch
+type EpochInfo struct {
+	// Epoch Number of epoch (starting from 0)
+	Epoch int
+	// Iterations Number of steps in epoch
+	Iterations int
+	// Losses Mean components of cost function over steps of epoch
+	Losses Losses
+	// LearningRate Learning rate at the end of epoch
+	LearningRate float64
+	// Duration Time of epoch
+	Duration time.Duration
+	// Elapsed Time since start of training
+	Elapsed time.Duration
+}
+
+// ValidationInfo Result of validation (see WithValidation())
+type ValidationInfo struct {
+	// Epoch Number of validated epoch (starting from 0)
+	Epoch int
+	// Images Number of validation images
+	Images int
+	// Losses Mean components of cost function over validation images
+	Losses Losses
+	// Duration Time of validation
+	Duration time.Duration
+}
+
+// CheckpointInfo Saved checkpoint (see WithCheckpoints())
+type CheckpointInfo struct {
+	// Epoch Number of saved epoch (starting from 0)
+	Epoch int
+	// Iteration Number of steps done before checkpoint
+	Iteration int
+	// Path Path to weights file
+	Path string
+}
+
+// TrainerCallbacks Hooks which are called by TrainFolder(), nil hooks are skipped
+/*
+	Returned error aborts training: TrainFolder() returns it (or nil in case of ErrStopTraining).
+	Hooks are called from goroutine of TrainFolder(), so they may use Trainer (e.g. change learning rate of its optimizer),
+	but must not train network.
+*/
+type TrainerCallbacks struct {
+	// OnIteration Called after every training step
+	OnIteration func(info IterationInfo) error
+	// OnEpoch Called after every epoch
+	OnEpoch func(info EpochInfo) error
+	// OnValidation Called after validation (see WithValidation())
+	OnValidation func(info ValidationInfo) error
+	// OnCheckpoint Called after checkpoint has been saved (see WithCheckpoints())
+	OnCheckpoint func(info CheckpointInfo) error
+}
+
+// WithCallbacks Sets hooks of TrainFolder() (by default every iteration is printed to stdout)
+func WithCallbacks(callbacks TrainerCallbacks) TrainerOption {
+	return func(opts *trainerOptions) {
+		opts.callbacks = &callbacks
+	}
+}
+
+// WithValidation Evaluates losses on labeled folder (see ParseLabeledFolder()) after every N-th epoch of TrainFolder()
+/*
+	Averaged weights are validated if EMA is enabled (see WithEMA()). Result is passed to TrainerCallbacks.OnValidation.
+*/
+func WithValidation(dir string, everyEpochs int) TrainerOption {
+	return func(opts *trainerOptions) {
+		opts.validation = &periodicOptions{
+			path:  dir,
+			every: everyEpochs,
+		}
+	}
+}
+
+// WithCheckpoints Saves weights (see Trainer.SaveWeights()) into folder after every N-th epoch of TrainFolder()
+/*
+	Files are named 'epoch_<number of finished epochs>.weights'. Saved checkpoint is passed to TrainerCallbacks.OnCheckpoint.
+*/
+func WithCheckpoints(dir string, everyEpochs int, half bool) TrainerOption {
+	return func(opts *trainerOptions) {
+		opts.checkpoints = &periodicOptions{
+			path:  dir,
+			every: everyEpochs,
+			half:  half,
+		}
+	}
+}
+
+// periodicOptions Parameters of action which is done after every N-th epoch
+type periodicOptions struct {
+	path  string
+	every int
+	half  bool
+}
+
+// due Checks if action should be done after given epoch (starting from 0)
+func (p *periodicOptions) due(epoch int) bool {
+	return p != nil && p.every > 0 && (epoch+1)%p.every == 0
+}
+
+// StopOnDivergence Returns OnIteration hook which aborts training when cost becomes NaN, infinite or exceeds maxCost
+/*
+	maxCost <= 0 disables the last check.
+*/
+func StopOnDivergence(maxCost float32) func(info IterationInfo) error {
+	return func(info IterationInfo) error {
+		cost := float64(info.Cost)
+		if math.IsNaN(cost) || math.IsInf(cost, 0) || (maxCost > 0 && info.Cost > maxCost) {
+			return fmt.Errorf("Training has diverged on iteration #%d (epoch #%d): cost is %v", info.Iteration, info.Epoch, info.Cost)
+		}
+		return nil
+	}
+}
+
+// printIteration Default OnIteration hook
+func printIteration(info IterationInfo) error {
+	fmt.Printf("Training iteration #%d (epoch #%d) done in: %v\n\tCurrent costs are: %v\n", info.Iteration, info.Epoch, info.StepTime, info.Cost)
+	return nil
+}
@@ -0,0 +1,106 @@
+package yologo
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrainerCallbacks(t *testing.T) {
+	dataDir, newTrainer := microTraining(t)
+	// Checkpoints are written next to folder of labeled images
+	checkpointsDir := filepath.Dir(dataDir)
+
+	iterations := []IterationInfo{}
+	epochs := []EpochInfo{}
+	validations := []ValidationInfo{}
+	checkpoints := []CheckpointInfo{}
+	callbacks := TrainerCallbacks{
+		OnIteration: func(info IterationInfo) error {
+			iterations = append(iterations, info)
+			return nil
+		},
+		OnEpoch: func(info EpochInfo) error {
+			epochs = append(epochs, info)
+			return nil
+		},
+		OnValidation: func(info ValidationInfo) error {
+			validations = append(validations, info)
+			return nil
+		},
+		OnCheckpoint: func(info CheckpointInfo) error {
+			checkpoints = append(checkpoints, info)
+			return nil
+		},
+	}
+	trainer := newTrainer(nil,
+		WithOptimizer(NewAdam(0.001, 0.9, 0.999)),
+		WithCallbacks(callbacks),
+		WithValidation(dataDir, 1),
+		WithCheckpoints(checkpointsDir, 2, false),
+	)
+	err := trainer.TrainFolder(dataDir, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !assert.Len(t, iterations, 9) || !assert.Len(t, epochs, 3) || !assert.Len(t, validations, 3) || !assert.Len(t, checkpoints, 1) {
+		return
+	}
+	for i, info := range iterations {
+		assert.Equal(t, i, info.Iteration)
+		assert.Equal(t, i/3, info.Epoch)
+		assert.Equal(t, 0.001, info.LearningRate)
+		assert.InDelta(t, info.Cost, info.Losses.Total(), 1e-3*float64(info.Cost), "Components of losses should sum up to cost")
+	}
+	for e, info := range epochs {
+		assert.Equal(t, e, info.Epoch)
+		assert.Equal(t, 3, info.Iterations)
+		mean := Losses{}
+		for _, it := range iterations[e*3 : e*3+3] {
+			mean.add(it.Losses)
+		}
+		assert.InDelta(t, mean.Total()/3, info.Losses.Total(), 1e-3*float64(info.Losses.Total()))
+		assert.Equal(t, e, validations[e].Epoch)
+		assert.Equal(t, 3, validations[e].Images)
+		assert.True(t, validations[e].Losses.Total() > 0)
+	}
+	assert.Equal(t, CheckpointInfo{Epoch: 1, Iteration: 6, Path: filepath.Join(checkpointsDir, "epoch_2.weights")}, checkpoints[0])
+	assert.FileExists(t, checkpoints[0].Path)
+
+	// Validation doesn't affect training
+	costs := trainMicro(t, dataDir, newTrainer, nil, WithOptimizer(NewAdam(0.001, 0.9, 0.999)))
+	for i := range costs {
+		assert.Equal(t, costs[i], iterations[i].Cost)
+	}
+}
+
+func TestTrainerCallbacksAbort(t *testing.T) {
+	dataDir, newTrainer := microTraining(t)
+
+	train := func(callbacks TrainerCallbacks) (int, error) {
+		steps := 0
+		onIteration := callbacks.OnIteration
+		callbacks.OnIteration = func(info IterationInfo) error {
+			steps++
+			if onIteration != nil {
+				return onIteration(info)
+			}
+			return nil
+		}
+		trainer := newTrainer(nil, WithCallbacks(callbacks))
+		err := trainer.TrainFolder(dataDir, 3)
+		return steps, err
+	}
+
+	steps, err := train(TrainerCallbacks{OnIteration: StopOnDivergence(1e-3)})
+	assert.Error(t, err)
+	assert.Equal(t, 1, steps)
+
+	steps, err = train(TrainerCallbacks{OnEpoch: func(info EpochInfo) error {
+		return ErrStopTraining
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, steps)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	yologo "github.com/LdDl/yolo-go"
+)
+
+func runAnchors(args []string) error {
+	fs := flag.NewFlagSet("anchors", flag.ExitOnError)
+	cfg := fs.String("cfg", "test_network_data/yolov3-tiny.cfg", "Path to net configuration file (size of network's input is taken from it)")
+	labelsFolder := fs.String("labels", "test_yolo_op_data", "Path to folder with darknet labels (*.txt files)")
+	k := fs.Int("k", 9, "Number of anchors")
+	iterations := fs.Int("iterations", 1000, "Maximal number of k-means iterations")
+	seed := fs.Int64("seed", 1, "Seed for initial choice of centroids")
+	fs.Parse(args)
+
+	width, height, _, err := networkInput(*cfg)
+	if err != nil {
+		return err
+	}
+	labeledData, err := yologo.ParseLabeledFolder(*labelsFolder)
+	if err != nil {
+		return err
+	}
+	// Iterate over files in order of names, so result is reproducible for given seed
+	names := make([]string, 0, len(labeledData))
+	for name := range labeledData {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sizes := []float32{}
+	for _, name := range names {
+		labels := labeledData[name]
+		// Every label is [class, x, y, w, h] with coordinates relative to size of image
+		for i := 0; i+4 < len(labels); i += 5 {
+			sizes = append(sizes, labels[i+3]*float32(width), labels[i+4]*float32(height))
+		}
+	}
+	anchors, avgIOU, err := yologo.KMeansAnchors(sizes, *k, *iterations, *seed)
+	if err != nil {
+		return err
+	}
+	pairs := make([]string, 0, *k)
+	for i := 0; i < len(anchors); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%d,%d", int(anchors[i]+0.5), int(anchors[i+1]+0.5)))
+	}
+	fmt.Printf("Boxes: %d\n", len(sizes)/2)
+	fmt.Printf("anchors = %s\n", strings.Join(pairs, ", "))
+	fmt.Printf("Average IoU: %.4f\n", avgIOU)
+	return nil
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	yologo "github.com/LdDl/yolo-go"
+)
+
+// detectJob Input image and file for its result
+type detectJob struct {
+	input  string
+	output string
+}
+
+// isImageFile Checks if file has extension of supported image format
+func isImageFile(fname string) bool {
+	switch strings.ToLower(filepath.Ext(fname)) {
+	case ".jpg", ".jpeg", ".png":
+		return true
+	}
+	return false
+}
+
+// resultFile Returns name of result file for image depending on output format
+func resultFile(fname, format string) string {
+	switch format {
+	case "json":
+		return strings.TrimSuffix(fname, filepath.Ext(fname)) + ".json"
+	case "darknet", "labels":
+		return strings.TrimSuffix(fname, filepath.Ext(fname)) + ".txt"
+	default:
+		return fname
+	}
+}
+
+// collectJobs Prepares jobs for every image in folder (recursively) or for given files
+/*
+	Results of folder's images mirror its structure inside outDir, results of files are placed into outDir by base names.
+	Folder outDir itself is never walked, so results of previous runs are not taken as input.
+*/
+func collectJobs(dir string, files []string, outDir, format string) ([]detectJob, error) {
+	jobs := []detectJob{}
+	if dir == "" {
+		for _, fname := range files {
+			jobs = append(jobs, detectJob{
+				input:  fname,
+				output: filepath.Join(outDir, resultFile(filepath.Base(fname), format)),
+			})
+		}
+		return jobs, nil
+	}
+	absOut, err := filepath.Abs(outDir)
+	if err != nil {
+		return nil, err
+	}
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if absPath, err := filepath.Abs(path); err == nil && absPath == absOut {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isImageFile(path) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		jobs = append(jobs, detectJob{
+			input:  path,
+			output: filepath.Join(outDir, resultFile(rel, format)),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// writeFileAtomic Writes file via temporary one in the same folder, so interrupted run never leaves partial result
+func writeFileAtomic(fname string, write func(w io.Writer) error) error {
+	err := os.MkdirAll(filepath.Dir(fname), 0755)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(fname), "."+filepath.Base(fname)+".tmp")
+	if err != nil {
+		return err
+	}
+	err = write(tmp)
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), fname)
+}
+
+// batchOptions Parameters of detection with per-image result files
+type batchOptions struct {
+	format         string
+	workers        int
+	resume         bool
+	thickness      int
+	scoreThreshold float32
+	iouThreshold   float32
+	// labelThreshold Minimal score of detection which becomes label in 'labels' format
+	labelThreshold float32
+	// copyImages Place images next to labels as '<name>.jpg' in 'labels' format
+	copyImages bool
+	// reviewFile List of images with detections scored below labelThreshold in 'labels' format (disabled if empty)
+	reviewFile string
+}
+
+// runDetectJobs Processes jobs by workers, every worker holds its own network
+/*
+	Failures of single images are reported to stderr and don't stop other images.
+*/
+func runDetectJobs(mf *modelFlags, classes []string, jobs []detectJob, opts batchOptions) error {
+	pending := jobs
+	if opts.resume {
+		pending = make([]detectJob, 0, len(jobs))
+		for _, job := range jobs {
+			if _, err := os.Stat(job.output); err == nil {
+				continue
+			}
+			pending = append(pending, job)
+		}
+		fmt.Fprintf(os.Stderr, "Skipping %d already processed images\n", len(jobs)-len(pending))
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	review := newReviewList(opts.reviewFile, opts.resume)
+	workers := yologo.MinInt(yologo.MaxInt(opts.workers, 1), len(pending))
+
+	models := make([]*yologo.YOLOv3, 0, workers)
+	detectors := make([]*yologo.Detector, 0, workers)
+	defer func() {
+		for _, detector := range detectors {
+			detector.Close()
+		}
+		for _, model := range models {
+			model.Close()
+		}
+	}()
+	for i := 0; i < workers; i++ {
+		model, err := mf.newModel(classes, 1, *mf.weights)
+		if err != nil {
+			return err
+		}
+		models = append(models, model)
+		detector, err := yologo.NewDetector(model, classes, opts.scoreThreshold, opts.iouThreshold)
+		if err != nil {
+			return err
+		}
+		detectors = append(detectors, detector)
+	}
+
+	jobsCh := make(chan detectJob)
+	failed := int64(0)
+	processed := int64(0)
+	var wg sync.WaitGroup
+	for _, detector := range detectors {
+		wg.Add(1)
+		go func(detector *yologo.Detector) {
+			defer wg.Done()
+			for job := range jobsCh {
+				n, uncertain, err := processJob(detector, job, opts)
+				if err != nil {
+					atomic.AddInt64(&failed, 1)
+					fmt.Fprintf(os.Stderr, "Can't process image '%s': %s\n", job.input, err.Error())
+					continue
+				}
+				review.update(job.input, uncertain)
+				if opts.format == "labels" {
+					fmt.Printf("[%d/%d] %s: %d objects (%d uncertain) -> %s\n", atomic.AddInt64(&processed, 1), len(pending), job.input, n, uncertain, job.output)
+					continue
+				}
+				fmt.Printf("[%d/%d] %s: %d objects -> %s\n", atomic.AddInt64(&processed, 1), len(pending), job.input, n, job.output)
+			}
+		}(detector)
+	}
+	for _, job := range pending {
+		jobsCh <- job
+	}
+	close(jobsCh)
+	wg.Wait()
+	if opts.reviewFile != "" {
+		err := review.write()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "List of images which need review has been saved to '%s'\n", opts.reviewFile)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d images have not been processed", failed, len(pending))
+	}
+	return nil
+}
+
+// processJob Detects objects on image of job and writes result file
+/*
+	Returns number of written detections and number of uncertain ones (scored below label threshold in 'labels' format).
+*/
+func processJob(detector *yologo.Detector, job detectJob, opts batchOptions) (int, int, error) {
+	img, err := yologo.ReadImage(job.input)
+	if err != nil {
+		return 0, 0, err
+	}
+	dets, err := detector.Detect(img)
+	if err != nil {
+		return 0, 0, err
+	}
+	uncertain := 0
+	if opts.format == "labels" {
+		dets, uncertain = splitByScore(dets, opts.labelThreshold)
+		if opts.copyImages {
+			// Image is placed before labels, so resumed run never sees labels without image
+			err = copyTrainingImage(job.input, strings.TrimSuffix(job.output, filepath.Ext(job.output))+".jpg", img)
+			if err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+	err = writeFileAtomic(job.output, func(w io.Writer) error {
+		return writeResult(w, job, img, dets, opts)
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(dets), uncertain, nil
+}
+
+// writeResult Writes detections of single image in given format
+func writeResult(w io.Writer, job detectJob, img image.Image, dets yologo.Detections, opts batchOptions) error {
+	switch opts.format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(newJSONResult(job.input, dets))
+	case "darknet":
+		_, err := io.WriteString(w, yologo.FormatLabels(dets, img.Bounds(), true))
+		return err
+	case "labels":
+		_, err := io.WriteString(w, yologo.FormatLabels(dets, img.Bounds(), false))
+		return err
+	default:
+		return encodeImage(w, job.output, yologo.DrawDetections(img, dets, opts.thickness))
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	yologo "github.com/LdDl/yolo-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTestImage Writes small PNG image (folders are created if needed)
+func writeTestImage(t *testing.T, fname string) {
+	err := os.MkdirAll(filepath.Dir(fname), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Create(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i * 7)
+	}
+	err = png.Encode(file, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectJobs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yolo_batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	images := filepath.Join(dir, "images")
+	for _, fname := range []string{"a.jpg", "b.PNG", filepath.Join("sub", "c.jpeg"), filepath.Join("results", "previous.jpg")} {
+		writeTestImage(t, filepath.Join(images, fname))
+	}
+	err = ioutil.WriteFile(filepath.Join(images, "notes.txt"), []byte("not an image"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := filepath.Join(images, "results")
+	outer := filepath.Join(dir, "results")
+
+	tests := []struct {
+		name     string
+		dir      string
+		files    []string
+		outDir   string
+		format   string
+		expected []detectJob
+	}{
+		{
+			name:   "folder is mirrored into output folder",
+			dir:    images,
+			outDir: outer,
+			format: "json",
+			expected: []detectJob{
+				{filepath.Join(images, "a.jpg"), filepath.Join(outer, "a.json")},
+				{filepath.Join(images, "b.PNG"), filepath.Join(outer, "b.json")},
+				{filepath.Join(images, "results", "previous.jpg"), filepath.Join(outer, "results", "previous.json")},
+				{filepath.Join(images, "sub", "c.jpeg"), filepath.Join(outer, "sub", "c.json")},
+			},
+		},
+		{
+			name:   "output folder inside input one is not walked",
+			dir:    images,
+			outDir: inner,
+			format: "darknet",
+			expected: []detectJob{
+				{filepath.Join(images, "a.jpg"), filepath.Join(inner, "a.txt")},
+				{filepath.Join(images, "b.PNG"), filepath.Join(inner, "b.txt")},
+				{filepath.Join(images, "sub", "c.jpeg"), filepath.Join(inner, "sub", "c.txt")},
+			},
+		},
+		{
+			name:   "labels are mirrored as darknet ones",
+			dir:    filepath.Join(images, "sub"),
+			outDir: outer,
+			format: "labels",
+			expected: []detectJob{
+				{filepath.Join(images, "sub", "c.jpeg"), filepath.Join(outer, "c.txt")},
+			},
+		},
+		{
+			name:   "output folder is matched by absolute path",
+			dir:    images,
+			outDir: filepath.Join(images, "sub", "..", "results"),
+			format: "image",
+			expected: []detectJob{
+				{filepath.Join(images, "a.jpg"), filepath.Join(inner, "a.jpg")},
+				{filepath.Join(images, "b.PNG"), filepath.Join(inner, "b.PNG")},
+				{filepath.Join(images, "sub", "c.jpeg"), filepath.Join(inner, "sub", "c.jpeg")},
+			},
+		},
+		{
+			name:   "files are placed by base names",
+			files:  []string{filepath.Join(images, "a.jpg"), filepath.Join(images, "sub", "c.jpeg")},
+			outDir: outer,
+			format: "json",
+			expected: []detectJob{
+				{filepath.Join(images, "a.jpg"), filepath.Join(outer, "a.json")},
+				{filepath.Join(images, "sub", "c.jpeg"), filepath.Join(outer, "c.json")},
+			},
+		},
+	}
+	for _, test := range tests {
+		jobs, err := collectJobs(test.dir, test.files, test.outDir, test.format)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err.Error())
+		}
+		assert.Equal(t, test.expected, jobs, test.name)
+	}
+
+	_, err = collectJobs(filepath.Join(dir, "missing"), nil, outer, "json")
+	assert.Error(t, err, "Missing folder should be reported")
+}
+
+func TestRunDetectJobsResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yolo_batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfg := "../../test_network_data/yolov3-micro.cfg"
+	weights := filepath.Join(dir, "micro.weights")
+	err = yologo.WriteSyntheticWeights(cfg, weights, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	half, mmap := false, false
+	mf := &modelFlags{cfg: &cfg, weights: &weights, half: &half, mmap: &mmap}
+
+	images := filepath.Join(dir, "images")
+	for _, fname := range []string{"a.png", "b.png", "c.png"} {
+		writeTestImage(t, filepath.Join(images, fname))
+	}
+	outDir := filepath.Join(dir, "results")
+	jobs, err := collectJobs(images, nil, outDir, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, jobs, 3) {
+		return
+	}
+	// Result of the second image is left by previous run
+	previous := []byte("previous run")
+	err = os.MkdirAll(outDir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(jobs[1].output, previous, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := batchOptions{
+		format:         "json",
+		workers:        2,
+		resume:         true,
+		scoreThreshold: 0.5,
+		iouThreshold:   0.45,
+	}
+	err = runDetectJobs(mf, []string{"first", "second"}, jobs, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, job := range jobs {
+		content, err := ioutil.ReadFile(job.output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 1 {
+			assert.Equal(t, previous, content, "Processed image should be skipped")
+		} else {
+			assert.Contains(t, string(content), job.input, "Result of '%s' should be written", job.input)
+		}
+	}
+
+	// Without resume every image is processed again
+	opts.resume = false
+	err = runDetectJobs(mf, []string{"first", "second"}, jobs, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(jobs[1].output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(content), jobs[1].input)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	yologo "github.com/LdDl/yolo-go"
+)
+
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	mf := addModelFlags(fs)
+	imagePath := fs.String("image", "test_network_data/dog_416x416.jpg", "Path to image file")
+	threads := fs.Int("threads", 0, "Number of threads (0 means number of CPUs)")
+	iterations := fs.Int("iterations", 10, "Number of forward passes")
+	synthetic := fs.Bool("synthetic", false, "Use random weights generated for configuration instead of weights file")
+	profileFile := fs.String("profile", "", "Path to Chrome trace JSON file with per-layer profile of forward passes (disabled if empty)")
+	scoreThreshold := fs.Float64("score", 0.8, "Score threshold (objectness multiplied by class probability)")
+	iouThreshold := fs.Float64("iou", 0.3, "IoU threshold for non-maximum suppression")
+	fs.Parse(args)
+
+	if *threads > 0 {
+		runtime.GOMAXPROCS(*threads)
+	}
+	if *iterations < 1 {
+		return fmt.Errorf("Number of iterations should be positive, but got %d", *iterations)
+	}
+	classes, err := mf.classes()
+	if err != nil {
+		return err
+	}
+
+	weightsFile := *mf.weights
+	if *synthetic {
+		dir, err := ioutil.TempDir("", "yolo_bench")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		weightsFile = filepath.Join(dir, "synthetic.weights")
+		err = yologo.WriteSyntheticWeights(*mf.cfg, weightsFile, 1)
+		if err != nil {
+			return err
+		}
+		// Synthetic weights are always stored as float32 values
+		*mf.half = false
+	}
+	timer := yologo.NewLayerTimer()
+	options := []yologo.ModelOption{yologo.WithLayerTimer(timer)}
+	profiler := yologo.NewProfiler()
+	if *profileFile != "" {
+		options = append(options, yologo.WithProfiler(profiler))
+	}
+	model, err := mf.newModel(classes, 1, weightsFile, options...)
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+	detector, err := yologo.NewDetector(model, classes, float32(*scoreThreshold), float32(*iouThreshold))
+	if err != nil {
+		return err
+	}
+	defer detector.Close()
+	img, err := yologo.ReadImage(*imagePath)
+	if err != nil {
+		return err
+	}
+
+	// Warm up
+	_, err = detector.Detect(img)
+	if err != nil {
+		return err
+	}
+	timer.Reset()
+	profiler.Reset()
+
+	st := time.Now()
+	for i := 0; i < *iterations; i++ {
+		_, err = detector.Detect(img)
+		if err != nil {
+			return err
+		}
+	}
+	elapsed := time.Since(st)
+
+	fmt.Println("Average time per layer:")
+	forward := time.Duration(0)
+	for _, timing := range timer.Timings() {
+		fmt.Println(timing)
+		forward += timing.Average
+	}
+	fmt.Printf("Threads: %d\n", runtime.GOMAXPROCS(0))
+	fmt.Printf("Forward pass: %v\n", forward)
+	fmt.Printf("Detection (with pre- and postprocessing): %v\n", elapsed/time.Duration(*iterations))
+	fmt.Printf("Images/sec: %.2f\n", float64(*iterations)/elapsed.Seconds())
+	if *profileFile != "" {
+		err = profiler.WriteChromeTraceFile(*profileFile)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Profile has been saved to '%s'\n", *profileFile)
+	}
+	return nil
+}
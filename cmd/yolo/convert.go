@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	yologo "github.com/LdDl/yolo-go"
+)
+
+func runConvert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	weights := fs.String("weights", "test_network_data/yolov3-tiny.weights", "Path to weights file")
+	half := fs.Bool("half", false, "Convert float16 weights file to float32 one (otherwise float32 file is converted to float16 one)")
+	outWeights := fs.String("out", "yolov3-tiny-converted.weights", "Path to output weights file")
+	fs.Parse(args)
+
+	err := yologo.ConvertWeights(*weights, *outWeights, !*half)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Weights have been converted and saved to '%s'\n", *outWeights)
+	return nil
+}
+
+func runPrune(args []string) error {
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	cfg := fs.String("cfg", "test_network_data/yolov3-tiny.cfg", "Path to net configuration file")
+	weights := fs.String("weights", "test_network_data/yolov3-tiny.weights", "Path to weights file")
+	half := fs.Bool("half", false, "Weights file stores float16 values (pruned weights are stored the same way)")
+	outCfg := fs.String("out-cfg", "yolov3-tiny-pruned.cfg", "Path to output net configuration file")
+	outWeights := fs.String("out", "yolov3-tiny-pruned.weights", "Path to output weights file")
+	pruneRatio := fs.Float64("ratio", 0.3, "Fraction of channels to remove from every convolution layer")
+	pruneCriterion := fs.String("criterion", "l1", "Criterion for ranking of convolution filters: l1/gamma")
+	fs.Parse(args)
+
+	criterion, err := yologo.ParsePruningCriterion(*pruneCriterion)
+	if err != nil {
+		return err
+	}
+	blocks, err := yologo.ParseConfiguration(*cfg)
+	if err != nil {
+		return err
+	}
+	var weightsData []float32
+	if *half {
+		weightsData, err = yologo.ParseWeightsF16(*weights)
+	} else {
+		weightsData, err = yologo.ParseWeights(*weights)
+	}
+	if err != nil {
+		return err
+	}
+	prunedBlocks, prunedWeights, err := yologo.PruneChannels(blocks, weightsData, criterion, float32(*pruneRatio))
+	if err != nil {
+		return err
+	}
+	err = yologo.WriteConfiguration(*outCfg, prunedBlocks)
+	if err != nil {
+		return err
+	}
+	err = yologo.WriteWeights(*outWeights, prunedWeights, *half)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Network has been pruned: %d -> %d parameters. Configuration is saved to '%s', weights are saved to '%s'\n", len(weightsData), len(prunedWeights), *outCfg, *outWeights)
+	return nil
+}
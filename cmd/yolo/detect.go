@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yologo "github.com/LdDl/yolo-go"
+)
+
+// jsonDetection JSON representation of single detection
+type jsonDetection struct {
+	Class      string  `json:"class"`
+	ClassIndex int     `json:"class_index"`
+	Score      float32 `json:"score"`
+	Confidence float32 `json:"confidence"`
+	// Box [x_min, y_min, x_max, y_max] in pixels of image
+	Box [4]int `json:"box"`
+}
+
+// jsonResult JSON representation of detections on single image
+type jsonResult struct {
+	Image      string          `json:"image"`
+	Detections []jsonDetection `json:"detections"`
+}
+
+func newJSONResult(fname string, dets yologo.Detections) jsonResult {
+	result := jsonResult{
+		Image:      fname,
+		Detections: make([]jsonDetection, 0, len(dets)),
+	}
+	for _, det := range dets {
+		rect := det.GetRectangle()
+		result.Detections = append(result.Detections, jsonDetection{
+			Class:      det.GetClass(),
+			ClassIndex: det.GetClassIndex(),
+			Score:      det.GetScore(),
+			Confidence: det.GetConfidence(),
+			Box:        [4]int{rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y},
+		})
+	}
+	return result
+}
+
+// expandInputs Expands glob patterns of arguments into list of files
+func expandInputs(args []string) ([]string, error) {
+	files := []string{}
+	for _, arg := range args {
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("Wrong pattern '%s': %s", arg, err.Error())
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("No files match '%s'", arg)
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// encodeImage Encodes image as PNG or JPEG depending on extension of file name
+func encodeImage(w io.Writer, fname string, img image.Image) error {
+	if strings.ToLower(filepath.Ext(fname)) == ".png" {
+		return png.Encode(w, img)
+	}
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: 95})
+}
+
+func runDetect(args []string) error {
+	fs := flag.NewFlagSet("detect", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage:\n\tyolo detect [flags] <image or glob pattern>...\n\tyolo detect [flags] -dir <folder>\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	mf := addModelFlags(fs)
+	scoreThreshold := fs.Float64("score", 0.8, "Score threshold (objectness multiplied by class probability)")
+	iouThreshold := fs.Float64("iou", 0.3, "IoU threshold for non-maximum suppression")
+	format := fs.String("format", "text", "Output format: text/json/image/darknet/labels (text and json are printed to stdout unless -dir is set, other formats are written per image)")
+	outDir := fs.String("out", "detections", "Folder for per-image results: annotated images, JSON files, darknet files (<class> <center_x> <center_y> <width> <height> <score>) or darknet labels")
+	thickness := fs.Int("thickness", 2, "Thickness of boxes on annotated images")
+	dir := fs.String("dir", "", "Folder to walk recursively for *.jpg, *.jpeg and *.png images (results mirror its structure in -out folder)")
+	workers := fs.Int("workers", 1, "Number of workers for per-image results, every worker holds its own network")
+	resume := fs.Bool("resume", false, "Skip images which already have result file in -out folder (continue interrupted run)")
+	labelScore := fs.Float64("label-score", 0.8, "Minimal score of detection which becomes label in 'labels' format (detections between -score and this threshold are listed in -review file)")
+	copyImages := fs.Bool("copy-images", true, "Place images next to labels as <name>.jpg in 'labels' format, so -out folder is ready for training")
+	reviewFile := fs.String("review", "", "File with list of images which need review in 'labels' format (default is needs_review.list in -out folder)")
+	fs.Parse(args)
+
+	*format = strings.ToLower(*format)
+	if *format != "text" && *format != "json" && *format != "image" && *format != "darknet" && *format != "labels" {
+		return fmt.Errorf("Unknown output format '%s'", *format)
+	}
+	if *format == "labels" {
+		if *labelScore < *scoreThreshold {
+			return fmt.Errorf("Label threshold %f should not be less than score threshold %f", *labelScore, *scoreThreshold)
+		}
+		if *reviewFile == "" {
+			*reviewFile = filepath.Join(*outDir, "needs_review.list")
+		}
+	} else {
+		*reviewFile = ""
+	}
+	files := []string{}
+	var err error
+	if *dir == "" {
+		files, err = expandInputs(fs.Args())
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("No input images are provided")
+		}
+	} else if fs.NArg() > 0 {
+		return fmt.Errorf("Folder and list of images can't be used together")
+	}
+
+	classes, err := mf.classes()
+	if err != nil {
+		return err
+	}
+
+	if *dir != "" || *format == "image" || *format == "darknet" || *format == "labels" {
+		if *format == "text" {
+			return fmt.Errorf("Format 'text' can't be written per image, use json or darknet one")
+		}
+		jobs, err := collectJobs(*dir, files, *outDir, *format)
+		if err != nil {
+			return err
+		}
+		return runDetectJobs(mf, classes, jobs, batchOptions{
+			format:         *format,
+			workers:        *workers,
+			resume:         *resume,
+			thickness:      *thickness,
+			scoreThreshold: float32(*scoreThreshold),
+			iouThreshold:   float32(*iouThreshold),
+			labelThreshold: float32(*labelScore),
+			copyImages:     *copyImages,
+			reviewFile:     *reviewFile,
+		})
+	}
+
+	model, err := mf.newModel(classes, 1, *mf.weights)
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+	detector, err := yologo.NewDetector(model, classes, float32(*scoreThreshold), float32(*iouThreshold))
+	if err != nil {
+		return err
+	}
+	defer detector.Close()
+
+	results := []jsonResult{}
+	for _, fname := range files {
+		img, err := yologo.ReadImage(fname)
+		if err != nil {
+			return fmt.Errorf("Can't read image '%s': %s", fname, err.Error())
+		}
+		dets, err := detector.Detect(img)
+		if err != nil {
+			return fmt.Errorf("Can't detect objects on image '%s': %s", fname, err.Error())
+		}
+		switch *format {
+		case "json":
+			results = append(results, newJSONResult(fname, dets))
+		default:
+			for _, det := range dets {
+				rect := det.GetRectangle()
+				fmt.Printf("%s\t%s\t%.4f\t%d\t%d\t%d\t%d\n", fname, det.GetClass(), det.GetScore()*det.GetConfidence(), rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y)
+			}
+		}
+	}
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+	return nil
+}
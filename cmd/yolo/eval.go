@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	yologo "github.com/LdDl/yolo-go"
+)
+
+func runEval(args []string) error {
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	mf := addModelFlags(fs)
+	evalFolder := fs.String("eval", "test_yolo_op_data", "Path to folder with labeled data (<name>.jpg and <name>.txt pairs)")
+	// Low score threshold is needed for mAP evaluation in order to obtain full precision-recall curve
+	scoreThreshold := fs.Float64("score", 0.005, "Score threshold (objectness multiplied by class probability)")
+	iouThreshold := fs.Float64("iou", 0.3, "IoU threshold for non-maximum suppression")
+	mapIOU := fs.Float64("map-iou", 0.5, "IoU threshold for matching detections with ground truth")
+	fs.Parse(args)
+
+	classes, err := mf.classes()
+	if err != nil {
+		return err
+	}
+	model, err := mf.newModel(classes, 1, *mf.weights)
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+	detector, err := yologo.NewDetector(model, classes, float32(*scoreThreshold), float32(*iouThreshold))
+	if err != nil {
+		return err
+	}
+	defer detector.Close()
+	result, err := yologo.EvaluateFolder(detector, *evalFolder, float32(*mapIOU))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Images: %d\n", result.Images)
+	for i, ap := range result.AP {
+		if ap < 0 {
+			continue
+		}
+		fmt.Printf("\t%s: AP = %f\n", classes[i], ap)
+	}
+	fmt.Printf("mAP@%.2f = %f\n", *mapIOU, result.MAP)
+	fmt.Printf("Average inference time: %v\n", result.InferenceTime)
+	return nil
+}
@@ -0,0 +1,64 @@
+// Command yolo Detection, training, evaluation and maintenance of YOLOv3 networks
+/*
+	Usage:
+		yolo <command> [flags] [arguments]
+	Run 'yolo <command> -h' for flags of command.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// command Subcommand of tool
+type command struct {
+	name        string
+	description string
+	run         func(args []string) error
+}
+
+var commands = []command{
+	{"detect", "Detect objects on images (text, JSON or annotated images output)", runDetect},
+	{"train", "Train network on labeled folder", runTrain},
+	{"eval", "Evaluate mAP of network on labeled folder", runEval},
+	{"summary", "Print layers, input and outputs of network", runSummary},
+	{"convert", "Convert weights between float32 and float16", runConvert},
+	{"prune", "Prune channels of convolution layers", runPrune},
+	{"quantize", "Calibrate int8 quantization and compare it with float32 network", runQuantize},
+	{"anchors", "Estimate anchors for labeled folder via k-means", runAnchors},
+	{"bench", "Benchmark network: per-layer timings and images/sec", runBench},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage:\n\tyolo <command> [flags] [arguments]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "\t%-10s %s\n", cmd.name, cmd.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'yolo <command> -h' for flags of command.\n")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	if name == "-h" || name == "--help" || name == "help" {
+		usage()
+		return
+	}
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		if err := cmd.run(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Can't run '%s' command due the error: %s\n", name, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Command '%s' is not implemented\n\n", name)
+	usage()
+	os.Exit(2)
+}
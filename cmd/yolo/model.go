@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+
+	yologo "github.com/LdDl/yolo-go"
+	"gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+const (
+	boxes     = 3
+	leakyCoef = 0.1
+)
+
+// modelFlags Flags which are common for every command which builds network
+type modelFlags struct {
+	cfg     *string
+	weights *string
+	names   *string
+	half    *bool
+	mmap    *bool
+}
+
+func addModelFlags(fs *flag.FlagSet) *modelFlags {
+	return &modelFlags{
+		cfg:     fs.String("cfg", "test_network_data/yolov3-tiny.cfg", "Path to net configuration file"),
+		weights: fs.String("weights", "test_network_data/yolov3-tiny.weights", "Path to weights file"),
+		names:   fs.String("names", "test_network_data/coco.names", "Path to file with names of classes (one per line)"),
+		half:    fs.Bool("half", false, "Weights file stores float16 values"),
+		mmap:    fs.Bool("mmap", false, "Map weights file into memory instead of reading it"),
+	}
+}
+
+// networkInput Returns width, height and number of channels of network's input from configuration
+func networkInput(cfgFile string) (int, int, int, error) {
+	blocks, err := yologo.ParseConfiguration(cfgFile)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	width, err := strconv.Atoi(blocks[0]["width"])
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("Network's width must be integer, got value: '%s'", blocks[0]["width"])
+	}
+	height, err := strconv.Atoi(blocks[0]["height"])
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("Network's height must be integer, got value: '%s'", blocks[0]["height"])
+	}
+	channels, err := yologo.InputChannels(blocks[0])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return width, height, channels, nil
+}
+
+// newModel Builds network on new graph for given batch size
+func (mf *modelFlags) newModel(classes []string, batchSize int, weightsFile string, options ...yologo.ModelOption) (*yologo.YOLOv3, error) {
+	width, height, channels, err := networkInput(*mf.cfg)
+	if err != nil {
+		return nil, err
+	}
+	if *mf.half {
+		options = append(options, yologo.WithHalfPrecisionWeights())
+	}
+	if *mf.mmap {
+		options = append(options, yologo.WithMappedWeights())
+	}
+	g := gorgonia.NewGraph()
+	input := gorgonia.NewTensor(g, tensor.Float32, 4, gorgonia.WithShape(batchSize, channels, height, width), gorgonia.WithName("input"))
+	return yologo.NewYoloV3(g, input, len(classes), boxes, leakyCoef, *mf.cfg, weightsFile, options...)
+}
+
+// classes Reads names of classes
+func (mf *modelFlags) classes() ([]string, error) {
+	return yologo.ParseClassNames(*mf.names)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	yologo "github.com/LdDl/yolo-go"
+)
+
+// splitByScore Returns detections scored not below threshold and number of the other ones
+func splitByScore(dets yologo.Detections, threshold float32) (yologo.Detections, int) {
+	confident := make(yologo.Detections, 0, len(dets))
+	for _, det := range dets {
+		if det.GetScore()*det.GetConfidence() >= threshold {
+			confident = append(confident, det)
+		}
+	}
+	return confident, len(dets) - len(confident)
+}
+
+// copyTrainingImage Places image as JPEG file next to its labels (training folder expects '<name>.jpg', see yologo.ParseLabeledFolder())
+func copyTrainingImage(input, output string, img image.Image) error {
+	absInput, err := filepath.Abs(input)
+	if err != nil {
+		return err
+	}
+	absOutput, err := filepath.Abs(output)
+	if err != nil {
+		return err
+	}
+	if absInput == absOutput {
+		return nil
+	}
+	ext := strings.ToLower(filepath.Ext(input))
+	if ext == ".jpg" || ext == ".jpeg" {
+		// Do not re-encode JPEG images
+		return writeFileAtomic(output, func(w io.Writer) error {
+			file, err := os.Open(input)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			_, err = io.Copy(w, file)
+			return err
+		})
+	}
+	return writeFileAtomic(output, func(w io.Writer) error {
+		return encodeImage(w, output, img)
+	})
+}
+
+// reviewList Images which have uncertain detections (scored below label threshold), safe for concurrent use
+type reviewList struct {
+	sync.Mutex
+	fname  string
+	images map[string]bool
+}
+
+// newReviewList Prepares list for given file; on resume images listed by previous run are kept
+func newReviewList(fname string, resume bool) *reviewList {
+	review := &reviewList{
+		fname:  fname,
+		images: map[string]bool{},
+	}
+	if fname == "" || !resume {
+		return review
+	}
+	file, err := os.Open(fname)
+	if err != nil {
+		return review
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			review.images[line] = true
+		}
+	}
+	return review
+}
+
+// update Adds image to list if it has uncertain detections, removes it otherwise
+func (review *reviewList) update(path string, uncertain int) {
+	review.Lock()
+	defer review.Unlock()
+	if uncertain > 0 {
+		review.images[path] = true
+	} else {
+		delete(review.images, path)
+	}
+}
+
+// write Writes paths of images (one per line, sorted)
+func (review *reviewList) write() error {
+	review.Lock()
+	defer review.Unlock()
+	paths := make([]string, 0, len(review.images))
+	for path := range review.images {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return writeFileAtomic(review.fname, func(w io.Writer) error {
+		for _, path := range paths {
+			if _, err := fmt.Fprintln(w, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
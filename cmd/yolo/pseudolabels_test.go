@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yolo_review")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "review.txt")
+	err = ioutil.WriteFile(fname, []byte("images/a.jpg\nimages/b.jpg\n\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := func() string {
+		content, err := ioutil.ReadFile(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+
+	// Images of previous run which are not processed again are kept
+	review := newReviewList(fname, true)
+	review.update("images/b.jpg", 0)
+	review.update("images/c.jpg", 2)
+	err = review.write()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "images/a.jpg\nimages/c.jpg\n", read())
+
+	// Image is dropped once it has no uncertain detections
+	review = newReviewList(fname, true)
+	review.update("images/a.jpg", 0)
+	review.update("images/c.jpg", 1)
+	err = review.write()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "images/c.jpg\n", read())
+
+	// Without resume list of previous run is replaced
+	review = newReviewList(fname, false)
+	review.update("images/d.jpg", 1)
+	err = review.write()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "images/d.jpg\n", read())
+
+	// Resume without list of previous run
+	review = newReviewList(filepath.Join(dir, "missing.txt"), true)
+	assert.Empty(t, review.images)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	yologo "github.com/LdDl/yolo-go"
+)
+
+func runQuantize(args []string) error {
+	fs := flag.NewFlagSet("quantize", flag.ExitOnError)
+	mf := addModelFlags(fs)
+	calibFolder := fs.String("calibration", "test_yolo_op_data", "Path to folder with *.jpg images for int8 calibration")
+	evalFolder := fs.String("eval", "test_yolo_op_data", "Path to folder with labeled data for mAP evaluation")
+	// Low score threshold is needed for mAP evaluation in order to obtain full precision-recall curve
+	scoreThreshold := fs.Float64("score", 0.005, "Score threshold (objectness multiplied by class probability)")
+	iouThreshold := fs.Float64("iou", 0.3, "IoU threshold for non-maximum suppression")
+	fs.Parse(args)
+
+	classes, err := mf.classes()
+	if err != nil {
+		return err
+	}
+	// Collect ranges of layers inputs for int8 quantization
+	calibrator := yologo.NewCalibrator()
+	model, err := mf.newModel(classes, 1, *mf.weights, yologo.WithCalibrator(calibrator))
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+	float32Detector, err := yologo.NewDetector(model, classes, float32(*scoreThreshold), float32(*iouThreshold))
+	if err != nil {
+		return err
+	}
+	defer float32Detector.Close()
+
+	// Do forward passes on calibration images
+	files, err := filepath.Glob(filepath.Join(*calibFolder, "*.jpg"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("Folder '%s' doesn't contain any *.jpg files for calibration", *calibFolder)
+	}
+	for i := range files {
+		_, err = float32Detector.DetectFile(files[i])
+		if err != nil {
+			return err
+		}
+	}
+	quantization, err := calibrator.Quantization(model)
+	if err != nil {
+		return err
+	}
+
+	// Prepare int8 network on separate graph
+	int8Model, err := mf.newModel(classes, 1, *mf.weights, yologo.WithQuantization(quantization))
+	if err != nil {
+		return err
+	}
+	defer int8Model.Close()
+	int8Detector, err := yologo.NewDetector(int8Model, classes, float32(*scoreThreshold), float32(*iouThreshold))
+	if err != nil {
+		return err
+	}
+	defer int8Detector.Close()
+
+	report, err := yologo.CompareQuantization(float32Detector, int8Detector, *evalFolder, 0.5)
+	if err != nil {
+		return err
+	}
+	fmt.Println(report)
+	return nil
+}
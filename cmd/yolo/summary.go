@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	yologo "github.com/LdDl/yolo-go"
+)
+
+func runSummary(args []string) error {
+	fs := flag.NewFlagSet("summary", flag.ExitOnError)
+	mf := addModelFlags(fs)
+	synthetic := fs.Bool("synthetic", false, "Use random weights generated for configuration instead of weights file (any configuration can be inspected)")
+	fs.Parse(args)
+
+	classes, err := mf.classes()
+	if err != nil {
+		return err
+	}
+	weightsFile := *mf.weights
+	if *synthetic {
+		dir, err := ioutil.TempDir("", "yolo_summary")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		weightsFile = filepath.Join(dir, "synthetic.weights")
+		err = yologo.WriteSyntheticWeights(*mf.cfg, weightsFile, 1)
+		if err != nil {
+			return err
+		}
+		*mf.half = false
+	}
+	var weightsData []float32
+	if *mf.half {
+		weightsData, err = yologo.ParseWeightsF16(weightsFile)
+	} else {
+		weightsData, err = yologo.ParseWeights(weightsFile)
+	}
+	if err != nil {
+		return err
+	}
+	model, err := mf.newModel(classes, 1, weightsFile)
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+
+	model.Print()
+	width, height, channels, err := networkInput(*mf.cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Input: %dx%dx%d\n", channels, height, width)
+	for i, out := range model.GetOutput() {
+		fmt.Printf("Output #%d: %v\n", i, out.Shape())
+	}
+	fmt.Printf("Classes: %d\n", len(classes))
+	// The first values of weights file are header
+	fmt.Printf("Parameters: %d\n", len(weightsData)-5)
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	yologo "github.com/LdDl/yolo-go"
+)
+
+// newOptimizer Creates optimizer by its name
+func newOptimizer(name string, learningRate, momentum, weightDecay, clipNorm float64) (yologo.Optimizer, error) {
+	options := []yologo.OptimizerOption{}
+	if weightDecay != 0 {
+		options = append(options, yologo.WithWeightDecay(weightDecay))
+	}
+	if clipNorm > 0 {
+		options = append(options, yologo.WithGradientClipping(clipNorm))
+	}
+	switch strings.ToLower(name) {
+	case "sgd":
+		return yologo.NewSGD(learningRate, momentum, options...), nil
+	case "adam":
+		return yologo.NewAdam(learningRate, momentum, 0.999, options...), nil
+	case "rmsprop":
+		return yologo.NewRMSProp(learningRate, 0.999, options...), nil
+	default:
+		return nil, fmt.Errorf("Unknown optimizer '%s'", name)
+	}
+}
+
+// trainingCallbacks Prints progress of training, aborts it on divergence of cost
+func trainingCallbacks(maxCost float32) yologo.TrainerCallbacks {
+	checkDivergence := yologo.StopOnDivergence(maxCost)
+	return yologo.TrainerCallbacks{
+		OnIteration: func(info yologo.IterationInfo) error {
+			fmt.Printf("Iteration #%d (epoch #%d, '%s') done in %v (waited for data %v), lr %g\n\tcost: %v (coords %v, obj %v, noobj %v, classes %v)\n",
+				info.Iteration, info.Epoch, info.Image, info.StepTime, info.WaitTime, info.LearningRate,
+				info.Cost, info.Losses.Coordinates, info.Losses.Objectness, info.Losses.NoObject, info.Losses.Classes,
+			)
+			return checkDivergence(info)
+		},
+		OnEpoch: func(info yologo.EpochInfo) error {
+			fmt.Printf("Epoch #%d: %d iterations done in %v (elapsed %v)\n\tmean cost: %v (coords %v, obj %v, noobj %v, classes %v)\n",
+				info.Epoch, info.Iterations, info.Duration, info.Elapsed,
+				info.Losses.Total(), info.Losses.Coordinates, info.Losses.Objectness, info.Losses.NoObject, info.Losses.Classes,
+			)
+			return nil
+		},
+		OnValidation: func(info yologo.ValidationInfo) error {
+			fmt.Printf("Validation of epoch #%d on %d images done in %v\n\tmean cost: %v (coords %v, obj %v, noobj %v, classes %v)\n",
+				info.Epoch, info.Images, info.Duration,
+				info.Losses.Total(), info.Losses.Coordinates, info.Losses.Objectness, info.Losses.NoObject, info.Losses.Classes,
+			)
+			return nil
+		},
+		OnCheckpoint: func(info yologo.CheckpointInfo) error {
+			fmt.Printf("Checkpoint of epoch #%d has been saved to '%s'\n", info.Epoch, info.Path)
+			return nil
+		},
+	}
+}
+
+func runTrain(args []string) error {
+	fs := flag.NewFlagSet("train", flag.ExitOnError)
+	mf := addModelFlags(fs)
+	trainingFolder := fs.String("train", "test_yolo_op_data", "Path to folder with labeled data (<name>.jpg and <name>.txt pairs)")
+	epochs := fs.Int("epochs", 1, "Number of passes over labeled data")
+	learningRate := fs.Float64("lr", 0.00001, "Learning rate")
+	optimizerName := fs.String("optimizer", "rmsprop", "Optimizer: sgd/adam/rmsprop")
+	momentum := fs.Float64("momentum", 0.9, "Momentum of SGD or decay rate of the first moment of Adam")
+	weightDecay := fs.Float64("weight-decay", 0, "Decoupled weight decay (Adam with weight decay is AdamW)")
+	clipNorm := fs.Float64("clip", 0, "Maximal global L2 norm of gradients (disabled if zero)")
+	emaDecay := fs.Float64("ema", 0, "Decay of exponential moving average of weights which is saved instead of raw weights (disabled if zero)")
+	emaWarmup := fs.Int("ema-warmup", 2000, "Number of steps of EMA warm-up")
+	seed := fs.Int64("seed", 0, "Seed of shuffling, augmentation and random initialization of weights (see -init), runs with the same seed are identical (random if zero)")
+	shuffle := fs.Bool("shuffle", false, "Shuffle order of images on each epoch")
+	flip := fs.Float64("flip", 0, "Probability of horizontal flip of training image")
+	exposure := fs.Float64("exposure", 0, "Maximal relative change of brightness of training image")
+	saturation := fs.Float64("saturation", 0, "Maximal relative change of saturation of training image")
+	workers := fs.Int("workers", 2, "Number of workers which decode and augment training images")
+	prefetch := fs.Int("prefetch", 4, "Number of prepared training images which are kept ahead of training")
+	initMethod := fs.String("init", "", "Initialize network randomly instead of reading -weights file: he/xavier/darknet (seed is taken from -seed)")
+	validationFolder := fs.String("val", "", "Path to folder with labeled data for validation after epochs (disabled if empty)")
+	validationEvery := fs.Int("val-every", 1, "Validate after every N-th epoch")
+	checkpointsFolder := fs.String("checkpoints", "", "Path to folder for weights files saved after epochs (disabled if empty)")
+	checkpointEvery := fs.Int("checkpoint-every", 1, "Save checkpoint after every N-th epoch")
+	maxCost := fs.Float64("max-cost", 0, "Abort training when cost of iteration exceeds this value (NaN and infinite costs always abort training, disabled if zero)")
+	outWeights := fs.String("out", "yolov3-tiny-trained.weights", "Path to output weights file")
+	fs.Parse(args)
+
+	optimizer, err := newOptimizer(*optimizerName, *learningRate, *momentum, *weightDecay, *clipNorm)
+	if err != nil {
+		return err
+	}
+	classes, err := mf.classes()
+	if err != nil {
+		return err
+	}
+	modelOptions := []yologo.ModelOption{}
+	if *initMethod != "" {
+		method, err := yologo.ParseWeightsInit(*initMethod)
+		if err != nil {
+			return err
+		}
+		if *seed == 0 {
+			*seed = time.Now().UnixNano()
+		}
+		modelOptions = append(modelOptions, yologo.WithRandomInit(method, *seed))
+	}
+	model, err := mf.newModel(classes, 1, *mf.weights, modelOptions...)
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+	trainerOptions := []yologo.TrainerOption{
+		yologo.WithOptimizer(optimizer),
+		yologo.WithDataLoader(*workers, *prefetch),
+		yologo.WithCallbacks(trainingCallbacks(float32(*maxCost))),
+	}
+	if *validationFolder != "" {
+		trainerOptions = append(trainerOptions, yologo.WithValidation(*validationFolder, *validationEvery))
+	}
+	if *checkpointsFolder != "" {
+		trainerOptions = append(trainerOptions, yologo.WithCheckpoints(*checkpointsFolder, *checkpointEvery, *mf.half))
+	}
+	if *seed != 0 {
+		trainerOptions = append(trainerOptions, yologo.WithSeed(*seed))
+	}
+	if *shuffle {
+		trainerOptions = append(trainerOptions, yologo.WithShuffle())
+	}
+	if *flip > 0 || *exposure > 0 || *saturation > 0 {
+		trainerOptions = append(trainerOptions, yologo.WithAugmentation(yologo.Augmentation{
+			Flip:       float32(*flip),
+			Exposure:   float32(*exposure),
+			Saturation: float32(*saturation),
+		}))
+	}
+	if *emaDecay > 0 {
+		trainerOptions = append(trainerOptions, yologo.WithEMA(*emaDecay, *emaWarmup))
+	}
+	trainer, err := yologo.NewTrainer(model, trainerOptions...)
+	if err != nil {
+		return err
+	}
+	defer trainer.Close()
+	err = trainer.TrainFolder(*trainingFolder, *epochs)
+	if err != nil {
+		return err
+	}
+	err = trainer.SaveWeights(*outWeights, *mf.half)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Trained weights have been saved to '%s'\n", *outWeights)
+	return nil
+}
@@ -8,6 +8,9 @@ import (
 	"gorgonia.org/tensor"
 )
 
+// batchNormEpsilon Epsilon which is added to variance in batch normalization
+const batchNormEpsilon = float32(0.000001)
+
 type convLayer struct {
 	filters            int
 	padding            int
@@ -23,6 +26,9 @@ type convLayer struct {
 
 	convNode *gorgonia.Node
 	biasNode *gorgonia.Node
+
+	calibrator   *Calibrator
+	quantization *convQuantization
 }
 
 func (l *convLayer) String() string {
@@ -37,22 +43,40 @@ func (l *convLayer) Type() string {
 }
 
 func (l *convLayer) ToNode(g *gorgonia.ExprGraph, inputs ...*gorgonia.Node) (*gorgonia.Node, error) {
+	input := inputs[0]
+
+	// Collect range of inputs for int8 calibration
+	if l.calibrator != nil {
+		hookedInput, err := hookNode(input, fmt.Sprintf("calibration_%d", l.layerIndex), l.calibrator.observer(l.layerIndex))
+		if err != nil {
+			return &gorgonia.Node{}, errors.Wrap(err, "Can't prepare calibration hook")
+		}
+		input = hookedInput
+	}
+
+	// Quantized convolution does bias addition and activation by itself
+	if l.quantization != nil {
+		op, err := newQuantizedConvOp(l)
+		if err != nil {
+			return &gorgonia.Node{}, errors.Wrap(err, "Can't prepare quantized convolution operation")
+		}
+		quantizedOut, err := gorgonia.ApplyOp(op, input)
+		if err != nil {
+			return &gorgonia.Node{}, errors.Wrap(err, "Can't apply quantized convolution operation")
+		}
+		return quantizedOut, nil
+	}
 
 	// Prepae Conv2D operation
-	convOut, err := gorgonia.Conv2d(inputs[0], l.convNode, tensor.Shape{l.kernelSize, l.kernelSize}, []int{l.padding, l.padding}, []int{l.stride, l.stride}, []int{1, 1})
+	convOut, err := gorgonia.Conv2d(input, l.convNode, tensor.Shape{l.kernelSize, l.kernelSize}, []int{l.padding, l.padding}, []int{l.stride, l.stride}, []int{1, 1})
 	if err != nil {
 		return &gorgonia.Node{}, errors.Wrap(err, "Can't prepare convolution operation")
 	}
 
 	// Prepare biases
 	shp := convOut.Shape()
-	iters := shp.TotalSize() / len(l.biases)
-	dataF32 := []float32{}
-	for b := 0; b < len(l.biases); b++ {
-		for j := 0; j < iters; j++ {
-			dataF32 = append(dataF32, l.biases[b])
-		}
-	}
+	dataF32 := make([]float32, shp.TotalSize())
+	fillBiases(dataF32, l.biases, shp[0])
 	biasTensor := tensor.New(tensor.WithBacking(dataF32), tensor.WithShape(shp...))
 	l.biasNode = gorgonia.NewTensor(g, tensor.Float32, 4, gorgonia.WithShape(shp...), gorgonia.WithName(fmt.Sprintf("bias_%d", l.layerIndex)), gorgonia.WithValue(biasTensor))
 	biasOut, err := gorgonia.Add(convOut, l.biasNode)
@@ -68,5 +92,35 @@ func (l *convLayer) ToNode(g *gorgonia.ExprGraph, inputs ...*gorgonia.Node) (*go
 		}
 		return activationOut, nil
 	}
-	return convOut, nil
+	return biasOut, nil
+}
+
+// setBiases Replaces biases of layer and refills prepared bias node (if it exists already)
+func (l *convLayer) setBiases(biases []float32) error {
+	if len(biases) != l.filters {
+		return fmt.Errorf("Convolution layer #%d expects %d biases, but got %d", l.layerIndex, l.filters, len(biases))
+	}
+	copy(l.biases, biases)
+	if l.biasNode == nil || l.biasNode.Value() == nil {
+		return nil
+	}
+	biasData, ok := l.biasNode.Value().Data().([]float32)
+	if !ok {
+		return fmt.Errorf("Bias node of convolution layer #%d should contain []float32", l.layerIndex)
+	}
+	fillBiases(biasData, l.biases, l.biasNode.Shape()[0])
+	return nil
+}
+
+// fillBiases Fills data of NCHW tensor (N = batchSize) with bias of corresponding channel
+func fillBiases(data, biases []float32, batchSize int) {
+	spatial := len(data) / (batchSize * len(biases))
+	for n := 0; n < batchSize; n++ {
+		for b := range biases {
+			start := (n*len(biases) + b) * spatial
+			for j := start; j < start+spatial; j++ {
+				data[j] = biases[b]
+			}
+		}
+	}
 }
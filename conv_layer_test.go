@@ -0,0 +1,52 @@
+package yologo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+func TestConvLayerBiases(t *testing.T) {
+	// Two filters of 1x1 kernel over single channel: output = kernel * input + bias (then activation)
+	input := []float32{1, -2, 3, -4}
+	kernels := []float32{2, -1}
+	biases := []float32{0.5, -1}
+	tests := []struct {
+		activation string
+		expected   []float32
+	}{
+		{"linear", []float32{2.5, -3.5, 6.5, -7.5, -2, 1, -4, 3}},
+		{"leaky", []float32{2.5, -0.35, 6.5, -0.75, -0.2, 1, -0.4, 3}},
+	}
+	for _, test := range tests {
+		g := gorgonia.NewGraph()
+		inputNode := gorgonia.NewTensor(g, tensor.Float32, 4, gorgonia.WithShape(1, 1, 2, 2), gorgonia.WithName("input"), gorgonia.WithValue(tensor.New(tensor.WithShape(1, 1, 2, 2), tensor.WithBacking(input))))
+		layer := &convLayer{
+			filters:            2,
+			kernelSize:         1,
+			stride:             1,
+			activation:         test.activation,
+			activationReLUCoef: 0.1,
+			biases:             biases,
+			convNode:           gorgonia.NewTensor(g, tensor.Float32, 4, gorgonia.WithShape(2, 1, 1, 1), gorgonia.WithName("conv_0"), gorgonia.WithValue(tensor.New(tensor.WithShape(2, 1, 1, 1), tensor.WithBacking(kernels)))),
+		}
+		layerOut, err := layer.ToNode(g, inputNode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Output is consumed by the next operation (as in network)
+		out, err := gorgonia.Mul(layerOut, gorgonia.NewConstant(float32(1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		vm := gorgonia.NewTapeMachine(g)
+		err = vm.RunAll()
+		vm.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.InDeltaSlice(t, test.expected, out.Value().(*tensor.Dense).Float32s(), 1e-6, "Wrong output of convolution layer with %s activation", test.activation)
+	}
+}
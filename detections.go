@@ -10,10 +10,11 @@ import (
 
 // DetectionRectangle Representation of detection
 type DetectionRectangle struct {
-	conf  float32
-	rect  image.Rectangle
-	class string
-	score float32
+	conf     float32
+	rect     image.Rectangle
+	class    string
+	classIdx int
+	score    float32
 }
 
 func (dr *DetectionRectangle) String() string {
@@ -27,6 +28,26 @@ func (dr *DetectionRectangle) GetClass() string {
 	return dr.class
 }
 
+// GetClassIndex Returns index of object's class in slice of classes
+func (dr *DetectionRectangle) GetClassIndex() int {
+	return dr.classIdx
+}
+
+// GetRectangle Returns bounding box of object
+func (dr *DetectionRectangle) GetRectangle() image.Rectangle {
+	return dr.rect
+}
+
+// GetScore Returns probability of object's class
+func (dr *DetectionRectangle) GetScore() float32 {
+	return dr.score
+}
+
+// GetConfidence Returns objectness confidence
+func (dr *DetectionRectangle) GetConfidence() float32 {
+	return dr.conf
+}
+
 // Detections Detection rectangles
 type Detections []*DetectionRectangle
 
@@ -49,48 +70,127 @@ func (detections DetectionsOrder) Less(i, j int) bool {
 	return detections[i].rect.Min.X < detections[j].rect.Min.X
 }
 
+// DetectionsLess Reports whether detection a should be placed before detection b
+type DetectionsLess func(a, b *DetectionRectangle) bool
+
+var (
+	// ByScore Orders detections by objectness confidence multiplied by class probability (highest first)
+	ByScore DetectionsLess = func(a, b *DetectionRectangle) bool {
+		return a.conf*a.score > b.conf*b.score
+	}
+	// ByX Orders detections by left border (left to right)
+	ByX DetectionsLess = func(a, b *DetectionRectangle) bool {
+		return a.rect.Min.X < b.rect.Min.X
+	}
+	// ByY Orders detections by top border (top to bottom)
+	ByY DetectionsLess = func(a, b *DetectionRectangle) bool {
+		return a.rect.Min.Y < b.rect.Min.Y
+	}
+	// ByArea Orders detections by area of bounding box (largest first)
+	ByArea DetectionsLess = func(a, b *DetectionRectangle) bool {
+		return a.rect.Dx()*a.rect.Dy() > b.rect.Dx()*b.rect.Dy()
+	}
+)
+
+// SortBy Sorts detections in place by given ordering. Sort is stable, so equal detections keep their positions
+func (detections Detections) SortBy(less DetectionsLess) {
+	sort.SliceStable(detections, func(i, j int) bool {
+		return less(detections[i], detections[j])
+	})
+}
+
+// OutputOption Option for ProcessOutput
+type OutputOption func(*outputOptions)
+
+type outputOptions struct {
+	order DetectionsLess
+}
+
+// WithDetectionsOrder Sorts final detections by given ordering (ByScore, ByX, ByY, ByArea or custom one). Default is ByX
+/*
+	Pass nil to keep order of non-maximum suppression (by score).
+*/
+func WithDetectionsOrder(less DetectionsLess) OutputOption {
+	return func(opts *outputOptions) {
+		opts.order = less
+	}
+}
+
 // ProcessOutput Returns postprocessed detections
-func (net *YOLOv3) ProcessOutput(classes []string, scoreTreshold, iouTreshold float32) (Detections, error) {
+/*
+	Detections are sorted by left border by default (see WithDetectionsOrder()).
+	For network with batch size greater than 1 detections of the first sample are returned (see ProcessBatchOutput()).
+*/
+func (net *YOLOv3) ProcessOutput(classes []string, scoreTreshold, iouTreshold float32, options ...OutputOption) (Detections, error) {
+	batch, err := net.ProcessBatchOutput(classes, scoreTreshold, iouTreshold, options...)
+	if err != nil {
+		return nil, err
+	}
+	if len(batch) == 0 {
+		return Detections{}, nil
+	}
+	return batch[0], nil
+}
+
+// ProcessBatchOutput Returns postprocessed detections for every sample of batch (see ProcessOutput())
+func (net *YOLOv3) ProcessBatchOutput(classes []string, scoreTreshold, iouTreshold float32, options ...OutputOption) ([]Detections, error) {
 	if len(classes) != net.classesNum {
 		return nil, fmt.Errorf("length of provided slice of classes is not equal to YOLO network 'classesNum' field")
 	}
-	preparedDetections := make(Detections, 0)
+	opts := outputOptions{
+		order: ByX,
+	}
+	for _, o := range options {
+		o(&opts)
+	}
+	var batch []Detections
+	var boxes [][]nmsBox
 	out := net.GetOutput()
 	for i := range out {
-		nodeValue := out[i].Value()
-		var tensorValue tensor.Tensor
-		switch nodeValue.(type) {
-		case tensor.Tensor:
-			tensorValue = nodeValue.(tensor.Tensor)
-			break
-		default:
-			fmt.Printf("Warning: YOLO output node #%d should be type of tensor.Tensor", i)
-			break
+		tensorValue, ok := out[i].Value().(tensor.Tensor)
+		if !ok {
+			return nil, fmt.Errorf("YOLO output node #%d should be type of tensor.Tensor", i)
 		}
-
-		dataValue := tensorValue.Data()
-		dataF32 := make([]float32, 0)
-		switch dataValue.(type) {
-		case []float32:
-			dataF32 = dataValue.([]float32)
-			break
-		default:
-			fmt.Printf("Warning: YOLO output tensor #%d should be type of []float32", i)
-			break
+		dataF32, ok := tensorValue.Data().([]float32)
+		if !ok {
+			return nil, fmt.Errorf("YOLO output tensor #%d should be type of []float32", i)
+		}
+		batchSize := tensorValue.Shape()[0]
+		if batch == nil {
+			batch = make([]Detections, batchSize)
+			boxes = make([][]nmsBox, batchSize)
+		}
+		if len(batch) != batchSize {
+			return nil, fmt.Errorf("YOLO output tensor #%d has batch size %d, but previous ones have %d", i, batchSize, len(batch))
+		}
+		sampleSize := len(dataF32) / batchSize
+		for b := 0; b < batchSize; b++ {
+			batch[b], boxes[b] = prepareDetections(dataF32[b*sampleSize:(b+1)*sampleSize], scoreTreshold, net.netSize, classes, batch[b], boxes[b])
 		}
-
-		detections := prepareDetections(dataF32, scoreTreshold, net.netSize, classes)
-		preparedDetections = append(preparedDetections, detections...)
 	}
-
-	finalDetections := nonMaxSupr(preparedDetections, iouTreshold)
-	sort.Sort(DetectionsOrder(finalDetections))
-	return finalDetections, nil
+	for b := range batch {
+		// Coordinates are rounded only for detections which survive suppression
+		kept := suppress(boxes[b], iouTreshold, WithGridNMS())
+		dets := make(Detections, len(kept))
+		for i := range kept {
+			dets[i] = batch[b][kept[i].idx]
+			dets[i].rect = kept[i].rectangle(net.netSize, net.netSize)
+		}
+		batch[b] = dets
+		if opts.order != nil {
+			batch[b].SortBy(opts.order)
+		}
+	}
+	return batch, nil
 }
 
 // prepareDetections Filter detections
-func prepareDetections(data []float32, scoreTreshold float32, netSize int, classes []string) Detections {
-	detections := make(Detections, 0)
+/*
+	Filtered detections are appended to detections without rectangles: their float boxes are appended to boxes
+	(in the same order, with positions in detections) and should be rounded after suppression (see nmsBox.rectangle()).
+*/
+func prepareDetections(data []float32, scoreTreshold float32, netSize int, classes []string, detections Detections, boxes []nmsBox) (Detections, []nmsBox) {
+	maxSize := float32(netSize)
 	for i := 0; i < len(data); i += (len(classes) + 5) {
 		class := 0
 		maxProbability := float32(0.0)
@@ -101,38 +201,16 @@ func prepareDetections(data []float32, scoreTreshold float32, netSize int, class
 			}
 		}
 		if maxProbability*data[i+4] > scoreTreshold {
-			box := &DetectionRectangle{
-				conf:  data[i+4],
-				rect:  Rectify(int(data[i]), int(data[i+1]), int(data[i+2]), int(data[i+3]), netSize, netSize),
-				class: classes[class],
-				score: maxProbability,
-			}
-			detections = append(detections, box)
-		}
-	}
-	return detections
-}
-
-// nonMaxSupr Sorts boxes by confidence
-func nonMaxSupr(detections Detections, iouTreshold float32) Detections {
-	sort.Sort(detections)
-	nms := make(Detections, 0)
-	if len(detections) == 0 {
-		return nms
-	}
-	nms = append(nms, detections[0])
-	for i := 1; i < len(detections); i++ {
-		tocheck, del := len(nms), false
-		for j := 0; j < tocheck; j++ {
-			currIOU := IOUFloat32(detections[i].rect, nms[j].rect)
-			if currIOU > iouTreshold && detections[i].class == nms[j].class {
-				del = true
-				break
-			}
-		}
-		if !del {
-			nms = append(nms, detections[i])
+			// Same geometry as Rectify(x, y, h, w, ...) gives for data[i:i+4]
+			x, y, h, w := data[i], data[i+1], data[i+2], data[i+3]
+			boxes = append(boxes, newNMSBox(maxF32(x-w/2, 0), maxF32(y-h/2, 0), minF32(x+w/2, maxSize), minF32(y+h/2, maxSize), maxProbability*data[i+4], class, len(detections)))
+			detections = append(detections, &DetectionRectangle{
+				conf:     data[i+4],
+				class:    classes[class],
+				classIdx: class,
+				score:    maxProbability,
+			})
 		}
 	}
-	return nms
+	return detections, boxes
 }
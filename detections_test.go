@@ -0,0 +1,66 @@
+package yologo
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+func TestDetectionsSortBy(t *testing.T) {
+	// Every ordering has ties, so stability matters
+	d0 := &DetectionRectangle{conf: 1, score: 0.5, rect: image.Rect(10, 10, 30, 30)}
+	d1 := &DetectionRectangle{conf: 1, score: 0.5, rect: image.Rect(10, 0, 20, 40)}
+	d2 := &DetectionRectangle{conf: 1, score: 0.8, rect: image.Rect(0, 10, 20, 30)}
+	d3 := &DetectionRectangle{conf: 0.5, score: 1, rect: image.Rect(5, 0, 35, 20)}
+	tests := []struct {
+		name     string
+		less     DetectionsLess
+		expected Detections
+	}{
+		{"ByScore", ByScore, Detections{d2, d0, d1, d3}},
+		{"ByX", ByX, Detections{d2, d3, d0, d1}},
+		{"ByY", ByY, Detections{d1, d3, d0, d2}},
+		{"ByArea", ByArea, Detections{d3, d0, d1, d2}},
+	}
+	for _, test := range tests {
+		dets := Detections{d0, d1, d2, d3}
+		dets.SortBy(test.less)
+		assert.Equal(t, test.expected, dets, "Wrong order of %s", test.name)
+		// Sorted detections keep their order
+		dets.SortBy(test.less)
+		assert.Equal(t, test.expected, dets, "Sorting is not stable for %s", test.name)
+	}
+}
+
+func TestProcessOutputOrder(t *testing.T) {
+	// Output of single YOLO layer for 2 classes: [x, y, w, h, objectness, class probabilities...]
+	// Boxes don't overlap, so non-maximum suppression keeps all of them ordered by score (right to left)
+	output := []float32{
+		20, 50, 14, 14, 1, 0.5, 0.1,
+		80, 50, 10, 10, 1, 0.1, 0.9,
+		50, 50, 20, 20, 1, 0.7, 0.2,
+		50, 90, 4, 4, 0.1, 0.1, 0.1,
+	}
+	g := gorgonia.NewGraph()
+	out := gorgonia.NewTensor(g, tensor.Float32, 3, gorgonia.WithShape(1, 4, 7), gorgonia.WithValue(tensor.New(tensor.WithShape(1, 4, 7), tensor.WithBacking(output))))
+	net := &YOLOv3{g: g, classesNum: 2, netSize: 100, out: []*gorgonia.Node{out}}
+	classes := []string{"first", "second"}
+
+	lefts := func(options ...OutputOption) []int {
+		dets, err := net.ProcessOutput(classes, 0.2, 0.45, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		xs := make([]int, len(dets))
+		for i := range dets {
+			xs[i] = dets[i].rect.Min.X
+		}
+		return xs
+	}
+	assert.Equal(t, []int{13, 40, 75}, lefts(), "Detections should be sorted by left border by default")
+	assert.Equal(t, []int{75, 40, 13}, lefts(WithDetectionsOrder(nil)), "Order of non-maximum suppression should be kept")
+	assert.Equal(t, []int{40, 13, 75}, lefts(WithDetectionsOrder(ByArea)))
+}
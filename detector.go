@@ -0,0 +1,204 @@
+package yologo
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg" // Register JPEG decoder for image.Decode()
+	_ "image/png"  // Register PNG decoder for image.Decode()
+	"os"
+
+	"github.com/pkg/errors"
+	"gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// Detector Wrapper around YOLOv3 network which prepares images, does forward pass and postprocesses output
+/*
+	Detections are returned in coordinates of original image.
+	Detector holds its own tape machine and is not safe for concurrent use.
+*/
+type Detector struct {
+	net            *YOLOv3
+	tm             gorgonia.VM
+	classes        []string
+	scoreThreshold float32
+	iouThreshold   float32
+	tta            *ttaOptions
+	tiling         *tilingOptions
+	roi            *roiOptions
+}
+
+// DetectorOption Option for NewDetector constructor
+type DetectorOption func(*detectorOptions)
+
+type detectorOptions struct {
+	tta    *ttaOptions
+	tiling *tilingOptions
+	roi    *roiOptions
+}
+
+// NewDetector Creates new detector for given network
+func NewDetector(net *YOLOv3, classes []string, scoreThreshold, iouThreshold float32, options ...DetectorOption) (*Detector, error) {
+	if len(classes) != net.classesNum {
+		return nil, fmt.Errorf("length of provided slice of classes is not equal to YOLO network 'classesNum' field")
+	}
+	if net.input == nil || net.g == nil {
+		return nil, fmt.Errorf("Network doesn't contain graph or input node")
+	}
+	opts := detectorOptions{}
+	for _, o := range options {
+		o(&opts)
+	}
+	if opts.tta != nil {
+		for _, scale := range opts.tta.scales {
+			if scale <= 0 || scale > 1 {
+				return nil, fmt.Errorf("Scales of test-time augmentation should be in range (0; 1], but got %f", scale)
+			}
+		}
+	}
+	if opts.tiling != nil {
+		if opts.tta != nil {
+			return nil, fmt.Errorf("Tiling can't be combined with test-time augmentation")
+		}
+		if opts.tiling.overlap < 0 || opts.tiling.overlap >= 1 {
+			return nil, fmt.Errorf("Overlap of tiles should be in range [0; 1), but got %f", opts.tiling.overlap)
+		}
+	}
+	if opts.roi != nil {
+		if err := opts.roi.mask.validate(); err != nil {
+			return nil, errors.Wrap(err, "Can't prepare ROI mask")
+		}
+	}
+	return &Detector{
+		net:            net,
+		tm:             gorgonia.NewTapeMachine(net.g),
+		classes:        classes,
+		scoreThreshold: scoreThreshold,
+		iouThreshold:   iouThreshold,
+		tta:            opts.tta,
+		tiling:         opts.tiling,
+		roi:            opts.roi,
+	}, nil
+}
+
+// Close Closes underlying tape machine
+func (d *Detector) Close() error {
+	return d.tm.Close()
+}
+
+// DetectFile Decodes image file (JPEG or PNG) and detects objects on it
+func (d *Detector) DetectFile(fname string) (Detections, error) {
+	img, err := ReadImage(fname)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Can't read image '%s'", fname))
+	}
+	return d.Detect(img)
+}
+
+// Detect Detects objects on image
+func (d *Detector) Detect(img image.Image) (Detections, error) {
+	if d.roi == nil {
+		return d.detect(img)
+	}
+	if d.roi.mask.BlankPixels {
+		img = d.roi.blank(img)
+	}
+	dets, err := d.detect(img)
+	if err != nil {
+		return nil, err
+	}
+	return d.roi.filter(dets), nil
+}
+
+// detect Detects objects on image according to mode of detector
+func (d *Detector) detect(img image.Image) (Detections, error) {
+	if d.tta != nil {
+		return d.detectAugmented(img)
+	}
+	if d.tiling != nil {
+		return d.detectTiled(img)
+	}
+	shp := d.net.input.Shape()
+	netHeight, netWidth := shp[2], shp[3]
+	dets, err := d.forward(scaleImage(img, netWidth, netHeight))
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	scaleDetections(dets, float32(bounds.Dx())/float32(netWidth), float32(bounds.Dy())/float32(netHeight), bounds.Min)
+	return dets, nil
+}
+
+// forward Runs network on image of network's size and returns detections in network's coordinates
+func (d *Detector) forward(img image.Image, options ...OutputOption) (Detections, error) {
+	batch, err := d.forwardBatch([]image.Image{img}, options...)
+	if err != nil {
+		return nil, err
+	}
+	return batch[0], nil
+}
+
+// forwardBatch Runs network on images of network's size and returns detections in network's coordinates for every image
+/*
+	Number of images should not exceed batch size of network's input; missing samples are filled with zeros.
+*/
+func (d *Detector) forwardBatch(imgs []image.Image, options ...OutputOption) ([]Detections, error) {
+	shp := d.net.input.Shape()
+	if len(imgs) > shp[0] {
+		return nil, fmt.Errorf("Network's input holds %d images, but got %d", shp[0], len(imgs))
+	}
+	sampleSize := shp.TotalSize() / shp[0]
+	batchF32 := make([]float32, shp.TotalSize())
+	for i := range imgs {
+		imgf32, err := Image2Float32(imgs[i])
+		if err != nil {
+			return nil, errors.Wrap(err, "Can't convert image to []float32")
+		}
+		if len(imgf32) != sampleSize {
+			return nil, fmt.Errorf("Image #%d should be of network's size (%d values), but got %d values", i, sampleSize, len(imgf32))
+		}
+		copy(batchF32[i*sampleSize:], imgf32)
+	}
+	imgTensor := tensor.New(tensor.WithShape(shp...), tensor.Of(tensor.Float32), tensor.WithBacking(batchF32))
+	err := gorgonia.Let(d.net.input, imgTensor)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't let input = []float32")
+	}
+	// Do not forget to reset tape machine after each run
+	defer d.tm.Reset()
+	if err := d.tm.RunAll(); err != nil {
+		return nil, errors.Wrap(err, "Can't run tape machine")
+	}
+	batch, err := d.net.ProcessBatchOutput(d.classes, d.scoreThreshold, d.iouThreshold, options...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't do postprocessing")
+	}
+	return batch[:len(imgs)], nil
+}
+
+// scaleDetections Maps detections from network's coordinates to image ones
+func scaleDetections(dets Detections, scaleX, scaleY float32, offset image.Point) {
+	for i := range dets {
+		rect := dets[i].rect
+		dets[i].rect = image.Rect(
+			int(float32(rect.Min.X)*scaleX)+offset.X,
+			int(float32(rect.Min.Y)*scaleY)+offset.Y,
+			int(float32(rect.Max.X)*scaleX)+offset.X,
+			int(float32(rect.Max.Y)*scaleY)+offset.Y,
+		)
+	}
+}
+
+// ReadImage Decodes image file (JPEG or PNG)
+func ReadImage(fname string) (image.Image, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
@@ -0,0 +1,46 @@
+package yologo
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// detectionsPalette Colors of bounding boxes (chosen by index of class)
+var detectionsPalette = []color.RGBA{
+	{R: 230, G: 25, B: 75, A: 255},
+	{R: 60, G: 180, B: 75, A: 255},
+	{R: 255, G: 225, B: 25, A: 255},
+	{R: 0, G: 130, B: 200, A: 255},
+	{R: 245, G: 130, B: 48, A: 255},
+	{R: 145, G: 30, B: 180, A: 255},
+	{R: 70, G: 240, B: 240, A: 255},
+	{R: 240, G: 50, B: 230, A: 255},
+	{R: 210, G: 245, B: 60, A: 255},
+	{R: 250, G: 190, B: 212, A: 255},
+	{R: 0, G: 128, B: 128, A: 255},
+	{R: 170, G: 110, B: 40, A: 255},
+}
+
+// DrawDetections Returns copy of image with bounding boxes of detections (color of box depends on class)
+func DrawDetections(img image.Image, dets Detections, thickness int) *image.RGBA {
+	bounds := img.Bounds()
+	annotated := image.NewRGBA(bounds)
+	draw.Draw(annotated, bounds, img, bounds.Min, draw.Src)
+	if thickness < 1 {
+		thickness = 1
+	}
+	for _, det := range dets {
+		rect := det.rect.Intersect(bounds)
+		if rect.Empty() {
+			continue
+		}
+		src := &image.Uniform{C: detectionsPalette[det.classIdx%len(detectionsPalette)]}
+		t := MinInt(thickness, MinInt(rect.Dx(), rect.Dy()))
+		draw.Draw(annotated, image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+t), src, image.ZP, draw.Src)
+		draw.Draw(annotated, image.Rect(rect.Min.X, rect.Max.Y-t, rect.Max.X, rect.Max.Y), src, image.ZP, draw.Src)
+		draw.Draw(annotated, image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+t, rect.Max.Y), src, image.ZP, draw.Src)
+		draw.Draw(annotated, image.Rect(rect.Max.X-t, rect.Min.Y, rect.Max.X, rect.Max.Y), src, image.ZP, draw.Src)
+	}
+	return annotated
+}
@@ -0,0 +1,101 @@
+package yologo
+
+import (
+	"fmt"
+	"math"
+
+	"gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// emaOptions Parameters of exponential moving average of weights
+type emaOptions struct {
+	decay  float64
+	warmup int
+}
+
+// WithEMA Keeps exponential moving average (EMA) of LearningNodes values which is updated after each optimizer step
+/*
+	Effective decay is decay * (1 - exp(-updates / warmup)), so average follows weights closely during the first steps
+	(warm-up is disabled if warmup <= 0). Averaged weights are used by Trainer.SaveWeights() and Trainer.RunWithEMA()
+	(e.g. for validation); training itself continues on raw weights.
+*/
+func WithEMA(decay float64, warmup int) TrainerOption {
+	return func(opts *trainerOptions) {
+		opts.ema = &emaOptions{
+			decay:  decay,
+			warmup: warmup,
+		}
+	}
+}
+
+// weightsEMA Shadow copy of values of nodes
+type weightsEMA struct {
+	emaOptions
+	updates int
+	shadow  [][]float32
+}
+
+// nodeFloat32s Returns backing slice of float32 value of node
+func nodeFloat32s(n *gorgonia.Node) ([]float32, error) {
+	dense, ok := n.Value().(*tensor.Dense)
+	if !ok || dense.Dtype() != tensor.Float32 {
+		return nil, fmt.Errorf("Value of node '%s' should be float32 tensor", n.Name())
+	}
+	return dense.Float32s(), nil
+}
+
+// newWeightsEMA Initializes average by current values of nodes
+func newWeightsEMA(nodes []*gorgonia.Node, opts emaOptions) (*weightsEMA, error) {
+	if opts.decay < 0 || opts.decay >= 1 {
+		return nil, fmt.Errorf("Decay of EMA should be in range [0; 1), but got %f", opts.decay)
+	}
+	ema := &weightsEMA{
+		emaOptions: opts,
+		shadow:     make([][]float32, len(nodes)),
+	}
+	for i, n := range nodes {
+		values, err := nodeFloat32s(n)
+		if err != nil {
+			return nil, err
+		}
+		ema.shadow[i] = append([]float32{}, values...)
+	}
+	return ema, nil
+}
+
+// update Moves average towards current values of nodes
+func (ema *weightsEMA) update(nodes []*gorgonia.Node) error {
+	ema.updates++
+	decay := ema.decay
+	if ema.warmup > 0 {
+		decay *= 1 - math.Exp(-float64(ema.updates)/float64(ema.warmup))
+	}
+	d := float32(decay)
+	for i, n := range nodes {
+		values, err := nodeFloat32s(n)
+		if err != nil {
+			return err
+		}
+		shadow := ema.shadow[i]
+		for j, v := range values {
+			shadow[j] = d*shadow[j] + (1-d)*v
+		}
+	}
+	return nil
+}
+
+// swap Exchanges values of nodes and average in place (the second call restores values)
+func (ema *weightsEMA) swap(nodes []*gorgonia.Node) error {
+	for i, n := range nodes {
+		values, err := nodeFloat32s(n)
+		if err != nil {
+			return err
+		}
+		shadow := ema.shadow[i]
+		for j := range values {
+			values[j], shadow[j] = shadow[j], values[j]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package yologo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+func TestWeightsEMA(t *testing.T) {
+	g := gorgonia.NewGraph()
+	values := []float32{1, 2}
+	node := gorgonia.NewTensor(g, tensor.Float32, 1, gorgonia.WithShape(2), gorgonia.WithName("w"), gorgonia.WithValue(tensor.New(tensor.WithShape(2), tensor.WithBacking(values))))
+	nodes := []*gorgonia.Node{node}
+
+	ema, err := newWeightsEMA(nodes, emaOptions{decay: 0.9, warmup: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values[0], values[1] = 11, 12
+	assert.NoError(t, ema.update(nodes))
+	assert.InDeltaSlice(t, []float32{2, 3}, ema.shadow[0], 1e-5)
+
+	// Swap exchanges values in place and restores them on the second call
+	assert.NoError(t, ema.swap(nodes))
+	assert.InDeltaSlice(t, []float32{2, 3}, values, 1e-5)
+	assert.NoError(t, ema.swap(nodes))
+	assert.Equal(t, []float32{11, 12}, values)
+
+	// Warm-up reduces decay of the first updates
+	warm, err := newWeightsEMA(nodes, emaOptions{decay: 0.9, warmup: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values[0], values[1] = 1, 2
+	assert.NoError(t, warm.update(nodes))
+	d := float32(0.9 * (1 - math.Exp(-0.1)))
+	assert.InDeltaSlice(t, []float32{d*11 + (1-d)*1, d*12 + (1-d)*2}, warm.shadow[0], 1e-5)
+
+	_, err = newWeightsEMA(nodes, emaOptions{decay: 1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,183 @@
+package yologo
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// EvaluationResult Accuracy and latency of detector on labeled data
+type EvaluationResult struct {
+	// MAP Mean of average precisions over classes which are present in ground truth
+	MAP float32
+	// AP Average precision for each class (-1 for classes which are absent in ground truth)
+	AP []float32
+	// Images Number of evaluated images
+	Images int
+	// InferenceTime Average time of single detection (including preprocessing and postprocessing)
+	InferenceTime time.Duration
+}
+
+// GroundTruth Annotated objects of single image in image's coordinates
+type GroundTruth struct {
+	Rects   []image.Rectangle
+	Classes []int
+}
+
+// NewGroundTruth Converts darknet annotations (see ParseLabels()) into ground truth for image of given size
+func NewGroundTruth(labels []float32, imgWidth, imgHeight int) GroundTruth {
+	gt := GroundTruth{}
+	w, h := float32(imgWidth), float32(imgHeight)
+	for i := 0; i+4 < len(labels); i += 5 {
+		cx, cy, bw, bh := labels[i+1]*w, labels[i+2]*h, labels[i+3]*w, labels[i+4]*h
+		gt.Rects = append(gt.Rects, image.Rect(int(cx-bw/2), int(cy-bh/2), int(cx+bw/2), int(cy+bh/2)))
+		gt.Classes = append(gt.Classes, int(labels[i]))
+	}
+	return gt
+}
+
+// EvaluateFolder Runs detector on every labeled image of folder (see ParseLabeledFolder()) and evaluates mAP
+func EvaluateFolder(det *Detector, dir string, iouThreshold float32) (*EvaluationResult, error) {
+	labeledData, err := ParseLabeledFolder(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't prepare labeled data")
+	}
+	names := make([]string, 0, len(labeledData))
+	for name := range labeledData {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	predictions := make([]Detections, 0, len(names))
+	truths := make([]GroundTruth, 0, len(names))
+	total := time.Duration(0)
+	for _, name := range names {
+		img, err := ReadImage(filepath.Join(dir, name+".jpg"))
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Can't read image for annotation '%s'", name))
+		}
+		st := time.Now()
+		dets, err := det.Detect(img)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("Can't detect objects on image '%s'", name))
+		}
+		total += time.Since(st)
+		predictions = append(predictions, dets)
+		truths = append(truths, NewGroundTruth(labeledData[name], img.Bounds().Dx(), img.Bounds().Dy()))
+	}
+
+	result := &EvaluationResult{
+		AP:     AveragePrecisions(predictions, truths, len(det.classes), iouThreshold),
+		Images: len(names),
+	}
+	result.MAP = meanAP(result.AP)
+	if len(names) > 0 {
+		result.InferenceTime = total / time.Duration(len(names))
+	}
+	return result, nil
+}
+
+// AveragePrecisions Evaluates average precision for each class (VOC-style, all-point interpolation)
+/*
+	predictions and truths should be of same length: i-th element corresponds to i-th image.
+	For classes which are absent in ground truth -1 is returned.
+*/
+func AveragePrecisions(predictions []Detections, truths []GroundTruth, classesNum int, iouThreshold float32) []float32 {
+	type scoredDetection struct {
+		image int
+		score float32
+		rect  image.Rectangle
+	}
+	perClass := make([][]scoredDetection, classesNum)
+	positives := make([]int, classesNum)
+	for i := range predictions {
+		for _, d := range predictions[i] {
+			if d.classIdx < 0 || d.classIdx >= classesNum {
+				continue
+			}
+			perClass[d.classIdx] = append(perClass[d.classIdx], scoredDetection{image: i, score: d.conf * d.score, rect: d.rect})
+		}
+	}
+	for i := range truths {
+		for _, c := range truths[i].Classes {
+			if c >= 0 && c < classesNum {
+				positives[c]++
+			}
+		}
+	}
+
+	aps := make([]float32, classesNum)
+	for c := 0; c < classesNum; c++ {
+		if positives[c] == 0 {
+			aps[c] = -1
+			continue
+		}
+		dets := perClass[c]
+		sort.SliceStable(dets, func(i, j int) bool { return dets[i].score > dets[j].score })
+		matched := make([][]bool, len(truths))
+		for i := range truths {
+			matched[i] = make([]bool, len(truths[i].Rects))
+		}
+		precisions := make([]float32, len(dets))
+		recalls := make([]float32, len(dets))
+		tp, fp := 0, 0
+		for k, d := range dets {
+			gt := truths[d.image]
+			bestIOU, bestIdx := float32(0.0), -1
+			for g := range gt.Rects {
+				if gt.Classes[g] != c {
+					continue
+				}
+				if iou := IOUFloat32(d.rect, gt.Rects[g]); iou > bestIOU {
+					bestIOU, bestIdx = iou, g
+				}
+			}
+			if bestIdx >= 0 && bestIOU >= iouThreshold && !matched[d.image][bestIdx] {
+				matched[d.image][bestIdx] = true
+				tp++
+			} else {
+				fp++
+			}
+			precisions[k] = float32(tp) / float32(tp+fp)
+			recalls[k] = float32(tp) / float32(positives[c])
+		}
+		aps[c] = interpolatedAP(precisions, recalls)
+	}
+	return aps
+}
+
+// interpolatedAP Area under precision-recall curve where precision is made monotonically decreasing
+func interpolatedAP(precisions, recalls []float32) float32 {
+	for i := len(precisions) - 2; i >= 0; i-- {
+		if precisions[i+1] > precisions[i] {
+			precisions[i] = precisions[i+1]
+		}
+	}
+	ap := float32(0.0)
+	prevRecall := float32(0.0)
+	for i := range recalls {
+		ap += (recalls[i] - prevRecall) * precisions[i]
+		prevRecall = recalls[i]
+	}
+	return ap
+}
+
+// meanAP Mean of average precisions skipping classes without ground truth
+func meanAP(aps []float32) float32 {
+	sum, n := float32(0.0), 0
+	for _, ap := range aps {
+		if ap < 0 {
+			continue
+		}
+		sum += ap
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float32(n)
+}
@@ -0,0 +1,54 @@
+package yologo
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpolatedAP(t *testing.T) {
+	// Precision is made monotonically decreasing: [1, 2/3, 2/3, 1/2], recall grows by 1/2 at steps #0 and #2
+	precisions := []float32{1, 0.5, 2.0 / 3.0, 0.5}
+	recalls := []float32{0.5, 0.5, 1, 1}
+	assert.InDelta(t, 0.5*1+0.5*2.0/3.0, interpolatedAP(precisions, recalls), 1e-6)
+	assert.Equal(t, float32(0), interpolatedAP([]float32{}, []float32{}))
+}
+
+func TestAveragePrecisions(t *testing.T) {
+	boxA := image.Rect(10, 10, 50, 50)
+	boxB := image.Rect(100, 100, 160, 140)
+	truths := []GroundTruth{
+		{Rects: []image.Rectangle{boxA}, Classes: []int{0}},
+		{Rects: []image.Rectangle{boxB}, Classes: []int{0}},
+	}
+	predictions := []Detections{
+		{
+			// True positive
+			&DetectionRectangle{conf: 1, score: 0.9, rect: boxA, classIdx: 0},
+			// Duplicate of already matched object is false positive
+			&DetectionRectangle{conf: 1, score: 0.6, rect: image.Rect(12, 10, 50, 50), classIdx: 0},
+			// Class without ground truth
+			&DetectionRectangle{conf: 1, score: 0.9, rect: boxA, classIdx: 1},
+		},
+		{
+			// False positive: no overlap with ground truth
+			&DetectionRectangle{conf: 1, score: 0.8, rect: image.Rect(0, 0, 20, 20), classIdx: 0},
+			// True positive with IoU above threshold
+			&DetectionRectangle{conf: 0.7, score: 1, rect: image.Rect(100, 100, 160, 130), classIdx: 0},
+		},
+	}
+	// Ranked detections of class #0: TP (0.9), FP (0.8), TP (0.7), FP (0.6)
+	// Precisions: 1, 1/2, 2/3, 1/2; recalls: 1/2, 1/2, 1, 1 => AP = 1/2 * 1 + 1/2 * 2/3
+	aps := AveragePrecisions(predictions, truths, 2, 0.5)
+	if !assert.Len(t, aps, 2) {
+		return
+	}
+	assert.InDelta(t, 0.5+1.0/3.0, aps[0], 1e-6)
+	assert.Equal(t, float32(-1), aps[1])
+	assert.InDelta(t, 0.5+1.0/3.0, meanAP(aps), 1e-6)
+
+	// Stricter IoU threshold turns the second true positive (IoU = 0.75) into false positive
+	aps = AveragePrecisions(predictions, truths, 2, 0.8)
+	assert.InDelta(t, 0.5, aps[0], 1e-6)
+}
@@ -0,0 +1,78 @@
+package yologo
+
+import (
+	"math"
+)
+
+// Float32ToFloat16 Converts float32 to bits of IEEE 754 half precision number (rounding to nearest even)
+func Float32ToFloat16(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int((bits >> 23) & 0xff)
+	mant := bits & 0x7fffff
+
+	// Infinity and NaN
+	if exp == 0xff {
+		if mant != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	}
+
+	e := exp - 127 + 15
+	// Overflow: too big for half precision
+	if e >= 0x1f {
+		return sign | 0x7c00
+	}
+	// Subnormal numbers (or zero after rounding)
+	if e <= 0 {
+		if e < -10 {
+			return sign
+		}
+		mant |= 0x800000
+		shift := uint(14 - e)
+		half := mant >> shift
+		rem := mant & (1<<shift - 1)
+		halfway := uint32(1) << (shift - 1)
+		if rem > halfway || (rem == halfway && half&1 == 1) {
+			half++
+		}
+		return sign | uint16(half)
+	}
+	// Rounding may carry into exponent, which is still correct (up to infinity)
+	half := uint32(e)<<10 | mant>>13
+	rem := mant & 0x1fff
+	if rem > 0x1000 || (rem == 0x1000 && half&1 == 1) {
+		half++
+	}
+	return sign | uint16(half)
+}
+
+// Float16ToFloat32 Converts bits of IEEE 754 half precision number to float32
+func Float16ToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+	var bits uint32
+	switch exp {
+	case 0x1f:
+		// Infinity and NaN
+		bits = sign | 0x7f800000 | mant<<13
+	case 0:
+		if mant == 0 {
+			bits = sign
+			break
+		}
+		// Subnormal half is normal float32: normalize mantissa
+		e := uint32(127 - 14)
+		for mant&0x400 == 0 {
+			mant <<= 1
+			e--
+		}
+		mant &= 0x3ff
+		bits = sign | e<<23 | mant<<13
+	default:
+		bits = sign | (exp+127-15)<<23 | mant<<13
+	}
+	return math.Float32frombits(bits)
+}
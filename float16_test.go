@@ -0,0 +1,107 @@
+package yologo
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFloat32ToFloat16(t *testing.T) {
+	smallestSubnormal := float32(math.Ldexp(1, -24))
+	tests := []struct {
+		name string
+		f    float32
+		half uint16
+	}{
+		{"zero", 0, 0x0000},
+		{"negative zero", float32(math.Copysign(0, -1)), 0x8000},
+		{"one", 1, 0x3c00},
+		{"minus two", -2, 0xc000},
+		{"tenth", 0.1, 0x2e66},
+		{"max half", 65504, 0x7bff},
+		{"smallest normal", float32(math.Ldexp(1, -14)), 0x0400},
+		{"largest subnormal", 1023 * smallestSubnormal, 0x03ff},
+		{"smallest subnormal", smallestSubnormal, 0x0001},
+		{"underflow", float32(math.Ldexp(1, -26)), 0x0000},
+		{"negative underflow", -float32(math.Ldexp(1, -26)), 0x8000},
+		{"overflow", 1e6, 0x7c00},
+		{"negative overflow", -1e6, 0xfc00},
+		{"rounding overflows to infinity", 65520, 0x7c00},
+		{"infinity", float32(math.Inf(1)), 0x7c00},
+		{"negative infinity", float32(math.Inf(-1)), 0xfc00},
+		// Halfway cases are rounded to even mantissa
+		{"halfway down to even", 1 + float32(math.Ldexp(1, -11)), 0x3c00},
+		{"halfway up to even", 1 + 3*float32(math.Ldexp(1, -11)), 0x3c02},
+		{"above halfway", 1 + 1.5*float32(math.Ldexp(1, -11)), 0x3c01},
+		{"subnormal halfway down to even", float32(math.Ldexp(1, -25)), 0x0000},
+		{"subnormal halfway up to even", 3 * float32(math.Ldexp(1, -25)), 0x0002},
+		{"subnormal above halfway", 1.5 * float32(math.Ldexp(1, -25)), 0x0001},
+		{"rounding carries into exponent", 1 - float32(math.Ldexp(1, -12)), 0x3c00},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.half, Float32ToFloat16(test.f), "Wrong half precision bits for %s (%g)", test.name, test.f)
+	}
+
+	nan := Float32ToFloat16(float32(math.NaN()))
+	assert.Equal(t, uint16(0x7c00), nan&0x7c00, "NaN should have maximal exponent")
+	assert.NotEqual(t, uint16(0), nan&0x03ff, "NaN should have non-zero mantissa")
+	assert.True(t, math.IsNaN(float64(Float16ToFloat32(nan))))
+}
+
+func TestFloat16ToFloat32(t *testing.T) {
+	assert.Equal(t, float32(1), Float16ToFloat32(0x3c00))
+	assert.Equal(t, float32(-2), Float16ToFloat32(0xc000))
+	assert.Equal(t, float32(65504), Float16ToFloat32(0x7bff))
+	assert.Equal(t, float32(math.Ldexp(1, -24)), Float16ToFloat32(0x0001))
+	assert.Equal(t, float32(math.Ldexp(1023, -24)), Float16ToFloat32(0x03ff))
+	assert.True(t, math.Signbit(float64(Float16ToFloat32(0x8000))), "Sign of negative zero should be kept")
+	assert.Equal(t, float32(math.Inf(-1)), Float16ToFloat32(0xfc00))
+
+	// Every half precision number (except NaN) is converted back to the same bits
+	for h := 0; h <= 0xffff; h++ {
+		half := uint16(h)
+		if half&0x7c00 == 0x7c00 && half&0x03ff != 0 {
+			continue
+		}
+		if back := Float32ToFloat16(Float16ToFloat32(half)); back != half {
+			t.Fatalf("Half precision 0x%04x is converted back to 0x%04x", half, back)
+		}
+	}
+}
+
+func TestWriteWeightsF16(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yolo_f16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// Header is stored as is, so it keeps values which are not representable in half precision
+	data := []float32{0, 2, 5, 123456789, 0, 1, -2, 0.1, 65504, 1e-8, 3.14159}
+	fname := filepath.Join(dir, "half.weights")
+	err = WriteWeights(fname, data, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(weightsHeaderSize*4+(len(data)-weightsHeaderSize)*2), info.Size())
+
+	parsed, err := ParseWeightsF16(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, parsed, len(data)) {
+		return
+	}
+	assert.Equal(t, data[:weightsHeaderSize], parsed[:weightsHeaderSize])
+	for i := weightsHeaderSize; i < len(data); i++ {
+		assert.Equal(t, Float16ToFloat32(Float32ToFloat16(data[i])), parsed[i], "Value #%d", i)
+		assert.InDelta(t, data[i], parsed[i], math.Abs(float64(data[i]))/1024+1e-7, "Value #%d", i)
+	}
+}
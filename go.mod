@@ -1,20 +1,31 @@
 module github.com/LdDl/yolo-go
 
-go 1.14
+go 1.17
 
 require (
-	github.com/apache/arrow/go/arrow v0.0.0-20201026153406-f6501a5ee16a // indirect
-	github.com/awalterschulze/gographviz v2.0.1+incompatible // indirect
 	github.com/chewxy/hm v1.0.0
 	github.com/chewxy/math32 v1.0.6
+	github.com/pkg/errors v0.9.1
+	github.com/stretchr/testify v1.6.1
+	gorgonia.org/gorgonia v0.9.15
+	gorgonia.org/tensor v0.9.14
+)
+
+require (
+	github.com/apache/arrow/go/arrow v0.0.0-20201026153406-f6501a5ee16a // indirect
+	github.com/awalterschulze/gographviz v2.0.1+incompatible // indirect
+	github.com/davecgh/go-spew v1.1.0 // indirect
 	github.com/gogo/protobuf v1.3.1 // indirect
 	github.com/golang/protobuf v1.4.3 // indirect
 	github.com/google/flatbuffers v1.12.0 // indirect
 	github.com/leesper/go_rng v0.0.0-20190531154944-a612b043e353 // indirect
-	github.com/pkg/errors v0.9.1
-	github.com/stretchr/testify v1.6.1
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/xtgo/set v1.0.0 // indirect
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 	gonum.org/v1/gonum v0.8.1 // indirect
-	gorgonia.org/gorgonia v0.9.15
-	gorgonia.org/tensor v0.9.14
+	google.golang.org/protobuf v1.25.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+	gorgonia.org/dawson v1.2.0 // indirect
+	gorgonia.org/vecf32 v0.9.0 // indirect
+	gorgonia.org/vecf64 v0.9.0 // indirect
 )
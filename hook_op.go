@@ -0,0 +1,116 @@
+package yologo
+
+import (
+	"fmt"
+	"hash"
+	"hash/fnv"
+
+	"github.com/chewxy/hm"
+	"github.com/pkg/errors"
+	"gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// hookOp Identity operation which passes its input through and calls hook function on every forward pass
+type hookOp struct {
+	name string
+	dims int
+	hook func(tensor.Tensor)
+}
+
+// hookNode Wraps node with identity operation which calls hook on every forward pass
+func hookNode(input *gorgonia.Node, name string, hook func(tensor.Tensor)) (*gorgonia.Node, error) {
+	op := &hookOp{
+		name: name,
+		dims: input.Dims(),
+		hook: hook,
+	}
+	return gorgonia.ApplyOp(op, input)
+}
+
+// layerObserver Receives marks of forward pass start and of every layer completion (see WithLayerTimer() and WithProfiler())
+type layerObserver interface {
+	register(layerIndex int, layer string)
+	startObserver() func(tensor.Tensor)
+	observer(layerIndex int) func(tensor.Tensor)
+}
+
+// startHook Joins start marks of every observer into single hook
+func startHook(observers []layerObserver) func(tensor.Tensor) {
+	hooks := make([]func(tensor.Tensor), len(observers))
+	for i := range observers {
+		hooks[i] = observers[i].startObserver()
+	}
+	return joinHooks(hooks)
+}
+
+// layerHook Registers layer in every observer and joins their marks of layer completion into single hook
+func layerHook(observers []layerObserver, layerIndex int, layer string) func(tensor.Tensor) {
+	hooks := make([]func(tensor.Tensor), len(observers))
+	for i := range observers {
+		observers[i].register(layerIndex, layer)
+		hooks[i] = observers[i].observer(layerIndex)
+	}
+	return joinHooks(hooks)
+}
+
+func joinHooks(hooks []func(tensor.Tensor)) func(tensor.Tensor) {
+	if len(hooks) == 1 {
+		return hooks[0]
+	}
+	return func(t tensor.Tensor) {
+		for _, hook := range hooks {
+			hook(t)
+		}
+	}
+}
+
+/* Methods to match gorgonia.Op interface */
+
+func (op *hookOp) Arity() int        { return 1 }
+func (op *hookOp) ReturnsPtr() bool  { return true }
+func (op *hookOp) CallsExtern() bool { return false }
+func (op *hookOp) WriteHash(h hash.Hash) {
+	fmt.Fprintf(h, "Hook{}(name: (%s))", op.name)
+}
+func (op *hookOp) Hashcode() uint32 {
+	h := fnv.New32a()
+	op.WriteHash(h)
+	return h.Sum32()
+}
+func (op *hookOp) String() string {
+	return fmt.Sprintf("Hook{}(name: (%s))", op.name)
+}
+func (op *hookOp) InferShape(inputs ...gorgonia.DimSizer) (tensor.Shape, error) {
+	return inputs[0].(tensor.Shape).Clone(), nil
+}
+func (op *hookOp) Type() hm.Type {
+	a := hm.TypeVariable('a')
+	t := gorgonia.TensorType{Dims: op.dims, Of: a}
+	return hm.NewFnType(t, t)
+}
+func (op *hookOp) OverwritesInput() int { return -1 }
+
+func (op *hookOp) Do(inputs ...gorgonia.Value) (gorgonia.Value, error) {
+	if err := checkArity(op, len(inputs)); err != nil {
+		return nil, errors.Wrap(err, "Can't check arity for hook")
+	}
+	in, ok := inputs[0].(tensor.Tensor)
+	if !ok {
+		return nil, errors.Errorf("Expected input to be a tensor")
+	}
+	if op.hook != nil {
+		op.hook(in)
+	}
+	return in, nil
+}
+
+func (op *hookOp) DiffWRT(inputs int) []bool { return []bool{true} }
+
+func (op *hookOp) SymDiff(inputs gorgonia.Nodes, output, grad *gorgonia.Node) (retVal gorgonia.Nodes, err error) {
+	if err = checkArity(op, len(inputs)); err != nil {
+		return
+	}
+	// Gradient of identity is gradient itself
+	return gorgonia.Nodes{grad}, nil
+}
@@ -0,0 +1,110 @@
+package yologo
+
+import (
+	"fmt"
+	"image"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ParseLabels Parses darknet annotation file
+/*
+	Each line of file should be in format: <class> <center_x> <center_y> <width> <height>
+	(coordinates are normalized to [0; 1] relative to image size).
+	Returns flattened slice: [class_1, x_1, y_1, w_1, h_1, class_2, ...]; empty file gives empty slice (image without objects).
+*/
+func ParseLabels(fname string) ([]float32, error) {
+	fileBytes, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	sliceOfF32 := []float32{}
+	fileContentAsArray := strings.Fields(string(fileBytes))
+	for j := range fileContentAsArray {
+		entityF32, err := strconv.ParseFloat(fileContentAsArray[j], 32)
+		if err != nil {
+			return nil, err
+		}
+		sliceOfF32 = append(sliceOfF32, float32(entityF32))
+	}
+	if len(sliceOfF32)%5 != 0 {
+		return nil, fmt.Errorf("Number of values in annotation file '%s' should be divided exactly by 5, but got %d", fname, len(sliceOfF32))
+	}
+	return sliceOfF32, nil
+}
+
+// ParseLabeledFolder Parses every darknet annotation file (*.txt) in folder
+/*
+	Returns map where key is name of file without extension (image is expected to be '<key>.jpg' in the same folder).
+	Images with empty annotation files are kept as background-only (negative) samples.
+*/
+func ParseLabeledFolder(dir string) (map[string][]float32, error) {
+	filesInfo, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	targets := map[string][]float32{}
+	for i := range filesInfo {
+		fileInfo := filesInfo[i]
+		// Parse only *.txt files
+		if fileInfo.IsDir() || filepath.Ext(fileInfo.Name()) != ".txt" {
+			continue
+		}
+		labels, err := ParseLabels(filepath.Join(dir, fileInfo.Name()))
+		if err != nil {
+			return nil, err
+		}
+		targets[strings.TrimSuffix(fileInfo.Name(), ".txt")] = labels
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("Folder '%s' doesn't contain any *.txt files (annotation files for YOLO)", dir)
+	}
+	return targets, nil
+}
+
+// ParseClassNames Parses darknet file with names of classes (*.names): one name per line, empty lines are skipped
+func ParseClassNames(fname string) ([]string, error) {
+	fileBytes, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	classes := []string{}
+	for _, line := range strings.Split(string(fileBytes), "\n") {
+		name := strings.TrimSpace(line)
+		if name == "" {
+			continue
+		}
+		classes = append(classes, name)
+	}
+	if len(classes) == 0 {
+		return nil, fmt.Errorf("File '%s' doesn't contain any class name", fname)
+	}
+	return classes, nil
+}
+
+// FormatLabels Formats detections in darknet annotation format (see ParseLabels()) relative to bounds of image
+/*
+	Each detection becomes line: <class> <center_x> <center_y> <width> <height>.
+	If withConfidence is set, score of detection (objectness multiplied by class probability) is appended to each line
+	(such lines are results of detection, not annotations for ParseLabels()).
+*/
+func FormatLabels(dets Detections, bounds image.Rectangle, withConfidence bool) string {
+	var sb strings.Builder
+	width, height := float32(bounds.Dx()), float32(bounds.Dy())
+	for _, det := range dets {
+		rect := det.rect.Intersect(bounds)
+		if rect.Empty() {
+			continue
+		}
+		cx := float32(rect.Min.X+rect.Max.X-2*bounds.Min.X) / 2 / width
+		cy := float32(rect.Min.Y+rect.Max.Y-2*bounds.Min.Y) / 2 / height
+		fmt.Fprintf(&sb, "%d %.6f %.6f %.6f %.6f", det.classIdx, cx, cy, float32(rect.Dx())/width, float32(rect.Dy())/height)
+		if withConfidence {
+			fmt.Fprintf(&sb, " %.6f", det.conf*det.score)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,38 @@
+package yologo
+
+import (
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatLabels(t *testing.T) {
+	bounds := image.Rect(0, 0, 400, 200)
+	dets := Detections{
+		&DetectionRectangle{conf: 1.0, score: 0.5, rect: image.Rect(100, 50, 200, 150), class: "dog", classIdx: 16},
+		// Box sticks out of image, so it is clipped
+		&DetectionRectangle{conf: 0.8, score: 1.0, rect: image.Rect(-40, 0, 40, 100), class: "person", classIdx: 0},
+	}
+	assert.Equal(t, "16 0.375000 0.500000 0.250000 0.500000 0.500000\n0 0.050000 0.250000 0.100000 0.500000 0.800000\n", FormatLabels(dets, bounds, true))
+
+	// Labels without confidence are valid annotations
+	dir, err := ioutil.TempDir("", "yolo_labels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "image.txt")
+	err = ioutil.WriteFile(fname, []byte(FormatLabels(dets, bounds, false)), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels, err := ParseLabels(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.InDeltaSlice(t, []float32{16, 0.375, 0.5, 0.25, 0.5, 0, 0.05, 0.25, 0.1, 0.5}, labels, 1e-6)
+}
@@ -0,0 +1,109 @@
+package yologo
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gorgonia.org/tensor"
+)
+
+// LayerTimer Accumulates wall time of every layer of network over forward passes
+/*
+	Usage:
+	1. Create network via NewYoloV3(..., WithLayerTimer(timer));
+	2. Run forward passes;
+	3. Call timer.Timings().
+	Every layer is marked by identity node which fires when layer's output is ready. Time of layer is time passed since previous mark,
+	so timings are meaningful for sequential execution of graph (e.g. via gorgonia.TapeMachine).
+*/
+type LayerTimer struct {
+	mu     sync.Mutex
+	last   time.Time
+	layers []string
+	totals []time.Duration
+	runs   int
+}
+
+// LayerTiming Average wall time of single layer
+type LayerTiming struct {
+	Index   int
+	Layer   string
+	Average time.Duration
+}
+
+// String Returns string representation of layer timing
+func (lt LayerTiming) String() string {
+	return fmt.Sprintf("#%-3d %12v  %s", lt.Index, lt.Average, lt.Layer)
+}
+
+// NewLayerTimer Creates new layer timer
+func NewLayerTimer() *LayerTimer {
+	return &LayerTimer{}
+}
+
+// Timings Returns average wall time of every layer per forward pass
+func (lt *LayerTimer) Timings() []LayerTiming {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	timings := make([]LayerTiming, len(lt.layers))
+	for i := range lt.layers {
+		timings[i] = LayerTiming{
+			Index: i,
+			Layer: lt.layers[i],
+		}
+		if lt.runs > 0 {
+			timings[i].Average = lt.totals[i] / time.Duration(lt.runs)
+		}
+	}
+	return timings
+}
+
+// Runs Returns number of measured forward passes
+func (lt *LayerTimer) Runs() int {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	return lt.runs
+}
+
+// Reset Drops collected timings (e.g. after warm-up passes)
+func (lt *LayerTimer) Reset() {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	for i := range lt.totals {
+		lt.totals[i] = 0
+	}
+	lt.runs = 0
+}
+
+// register Adds layer description
+func (lt *LayerTimer) register(layerIndex int, layer string) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	for len(lt.layers) <= layerIndex {
+		lt.layers = append(lt.layers, "")
+		lt.totals = append(lt.totals, 0)
+	}
+	lt.layers[layerIndex] = layer
+}
+
+// startObserver Returns hook which marks start of forward pass (network input is ready)
+func (lt *LayerTimer) startObserver() func(tensor.Tensor) {
+	return func(t tensor.Tensor) {
+		lt.mu.Lock()
+		lt.last = time.Now()
+		lt.runs++
+		lt.mu.Unlock()
+	}
+}
+
+// observer Returns hook which marks end of given layer
+func (lt *LayerTimer) observer(layerIndex int) func(tensor.Tensor) {
+	return func(t tensor.Tensor) {
+		lt.mu.Lock()
+		now := time.Now()
+		lt.totals[layerIndex] += now.Sub(lt.last)
+		lt.last = now
+		lt.mu.Unlock()
+	}
+}
@@ -0,0 +1,70 @@
+package yologo
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+func TestLayerTimer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yolo_timer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	weightsFile := filepath.Join(dir, "micro.weights")
+	writeTestWeights(t, "./test_network_data/yolov3-micro.cfg", weightsFile, 1)
+	timer := NewLayerTimer()
+	model := newMicroModel(t, weightsFile, WithLayerTimer(timer))
+
+	runs := 3
+	rng := rand.New(rand.NewSource(1))
+	vm := gorgonia.NewTapeMachine(model.g)
+	defer vm.Close()
+	for i := 0; i < runs; i++ {
+		err = gorgonia.Let(model.input, tensor.New(tensor.WithShape(1, 3, 32, 32), tensor.WithBacking(randomFloat32s(rng, 3*32*32))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = vm.RunAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		vm.Reset()
+	}
+	assert.Equal(t, runs, timer.Runs())
+
+	// Every layer of configuration is timed in order: convolutional, maxpool, convolutional, convolutional, yolo
+	blocks, err := ParseConfiguration("./test_network_data/yolov3-micro.cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefixes := map[string]string{
+		"convolutional": "Convolution layer",
+		"maxpool":       "Maxpooling layer",
+		"yolo":          "YOLO layer",
+	}
+	timings := timer.Timings()
+	if !assert.Len(t, timings, len(blocks)-1) {
+		return
+	}
+	for i, timing := range timings {
+		assert.Equal(t, i, timing.Index)
+		assert.Equal(t, model.layersInfo[i], timing.Layer, "Wrong description of layer #%d", i)
+		assert.True(t, strings.HasPrefix(timing.Layer, prefixes[blocks[i+1]["type"]]), "Layer #%d should be '%s', but got '%s'", i, blocks[i+1]["type"], timing.Layer)
+		assert.GreaterOrEqual(t, int64(timing.Average), int64(0), "Negative duration of layer #%d", i)
+	}
+
+	timer.Reset()
+	assert.Equal(t, 0, timer.Runs())
+	for _, timing := range timer.Timings() {
+		assert.Zero(t, timing.Average)
+	}
+}
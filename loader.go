@@ -0,0 +1,130 @@
+package yologo
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type loaderOptions struct {
+	workers  int
+	prefetch int
+}
+
+// WithDataLoader Sets number of workers which decode, augment and convert images of TrainFolder() and number of
+// prepared samples which are kept ahead of training (defaults are 2 and 4)
+/*
+	Samples are delivered in the same order and augmented in the same way for any number of workers:
+	every sample gets its own seed from random source of trainer (see WithSeed()).
+*/
+func WithDataLoader(workers, prefetch int) TrainerOption {
+	return func(opts *trainerOptions) {
+		opts.loader = loaderOptions{
+			workers:  workers,
+			prefetch: prefetch,
+		}
+	}
+}
+
+// trainingSample Image of network's input size with its annotations
+type trainingSample struct {
+	name   string
+	epoch  int
+	img    []float32
+	target []float32
+	err    error
+}
+
+// loadJob Sample to prepare and slot for result
+type loadJob struct {
+	name   string
+	epoch  int
+	seed   int64
+	result chan trainingSample
+}
+
+// loadSamples Prepares samples of every epoch in pool of workers
+/*
+	Returns bounded queue of slots in order of training; every slot receives single sample.
+	Closing of done stops loading (e.g. on error of training step); returned finished channel is closed when loading
+	goroutines have exited, so random source of trainer is free again.
+*/
+func (t *Trainer) loadSamples(dir string, names []string, labeledData map[string][]float32, epochs int, done <-chan struct{}) (<-chan chan trainingSample, <-chan struct{}) {
+	workers := MaxInt(t.opts.loader.workers, 1)
+	queue := make(chan chan trainingSample, MaxInt(t.opts.loader.prefetch, 0))
+	jobs := make(chan loadJob)
+	finished := make(chan struct{})
+	shp := t.net.input.Shape()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				// Slot is buffered, so worker never waits for consumer
+				job.result <- t.prepareSample(dir, job, labeledData[job.name], shp[3], shp[2])
+			}
+		}()
+	}
+
+	go func() {
+		defer func() {
+			close(jobs)
+			wg.Wait()
+			close(queue)
+			close(finished)
+		}()
+		order := append([]string{}, names...)
+		for epoch := 0; epoch < epochs; epoch++ {
+			// Random source of trainer is used by this goroutine only, so order and seeds don't depend on workers
+			if t.opts.shuffle {
+				t.rng.Shuffle(len(order), func(i, j int) {
+					order[i], order[j] = order[j], order[i]
+				})
+			}
+			for _, name := range order {
+				job := loadJob{
+					name:   name,
+					epoch:  epoch,
+					result: make(chan trainingSample, 1),
+				}
+				if t.opts.augmentation != nil {
+					job.seed = t.rng.Int63()
+				}
+				select {
+				case queue <- job.result:
+				case <-done:
+					return
+				}
+				select {
+				case jobs <- job:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+	return queue, finished
+}
+
+// prepareSample Reads image of job and augments it
+func (t *Trainer) prepareSample(dir string, job loadJob, target []float32, width, height int) trainingSample {
+	sample := trainingSample{
+		name:   job.name,
+		epoch:  job.epoch,
+		target: target,
+	}
+	sample.img, sample.err = GetFloat32Image(filepath.Join(dir, job.name+".jpg"), width, height)
+	if sample.err != nil {
+		sample.err = errors.Wrap(sample.err, fmt.Sprintf("Can't read []float32 from image '%s'", job.name))
+		return sample
+	}
+	if t.opts.augmentation != nil {
+		sample.img, sample.target = t.opts.augmentation.apply(rand.New(rand.NewSource(job.seed)), sample.img, width, height, target)
+	}
+	return sample
+}
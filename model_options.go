@@ -0,0 +1,61 @@
+package yologo
+
+// ModelOption Option for NewYoloV3 constructor
+type ModelOption func(*modelOptions)
+
+type modelOptions struct {
+	calibrator     *Calibrator
+	quantization   *Quantization
+	halfWeights    bool
+	mapWeights     bool
+	randomInit     *randomInitOptions
+	layerObservers []layerObserver
+}
+
+// WithCalibrator Collects ranges of convolution layers inputs into provided calibrator on every forward pass
+func WithCalibrator(calibrator *Calibrator) ModelOption {
+	return func(opts *modelOptions) {
+		opts.calibrator = calibrator
+	}
+}
+
+// WithQuantization Replaces float32 convolution layers (which are present in provided quantization) with int8 ones
+func WithQuantization(quantization *Quantization) ModelOption {
+	return func(opts *modelOptions) {
+		opts.quantization = quantization
+	}
+}
+
+// WithHalfPrecisionWeights Reads weights file as float16 one (see ParseWeightsF16())
+func WithHalfPrecisionWeights() ModelOption {
+	return func(opts *modelOptions) {
+		opts.halfWeights = true
+	}
+}
+
+// WithMappedWeights Maps weights file into memory instead of reading it (unix-like little-endian hosts only)
+/*
+	Kernels and biases are backed by mapped region directly, so startup doesn't depend on size of weights
+	and processes which use the same file share its pages. Batch normalization is folded into weights at load time,
+	so pages of such layers are copied: use weights written by YOLOv3.SaveWeights() in order to share all of them.
+	Network should be closed via YOLOv3.Close() to release mapping.
+*/
+func WithMappedWeights() ModelOption {
+	return func(opts *modelOptions) {
+		opts.mapWeights = true
+	}
+}
+
+// WithLayerTimer Measures wall time of every layer on every forward pass into provided timer
+func WithLayerTimer(timer *LayerTimer) ModelOption {
+	return func(opts *modelOptions) {
+		opts.layerObservers = append(opts.layerObservers, timer)
+	}
+}
+
+// WithProfiler Records wall time and allocations of every layer on every forward pass into provided profiler
+func WithProfiler(profiler *Profiler) ModelOption {
+	return func(opts *modelOptions) {
+		opts.layerObservers = append(opts.layerObservers, profiler)
+	}
+}
@@ -0,0 +1,243 @@
+package yologo
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// gridNMSMinBoxes Minimal number of boxes of single class for which grid lookup is used
+const gridNMSMinBoxes = 64
+
+// gridNMSMaxCells Maximal number of grid cells along each axis
+const gridNMSMaxCells = 128
+
+// NMSOption Option for NonMaxSuppression
+type NMSOption func(*nmsOptions)
+
+type nmsOptions struct {
+	grid bool
+}
+
+// WithGridNMS Looks up overlapping boxes via uniform grid instead of checking every kept box
+/*
+	Result is the same as for plain greedy suppression, but it scales much better for thousands of candidates of the same class
+	(e.g. outputs with low score threshold). Classes with less than 64 candidates are processed without grid.
+*/
+func WithGridNMS() NMSOption {
+	return func(opts *nmsOptions) {
+		opts.grid = true
+	}
+}
+
+// nmsBox Float representation of detection with precomputed area
+type nmsBox struct {
+	x1, y1, x2, y2 float32
+	area           float32
+	rank           float32
+	classIdx       int
+	// idx Position of detection in input slice
+	idx int
+}
+
+// newNMSBox Creates box of given corners
+func newNMSBox(x1, y1, x2, y2, rank float32, classIdx, idx int) nmsBox {
+	return nmsBox{
+		x1:       x1,
+		y1:       y1,
+		x2:       x2,
+		y2:       y2,
+		area:     (x2 - x1) * (y2 - y1),
+		rank:     rank,
+		classIdx: classIdx,
+		idx:      idx,
+	}
+}
+
+func (b *nmsBox) iou(other *nmsBox) float32 {
+	w := minF32(b.x2, other.x2) - maxF32(b.x1, other.x1)
+	if w <= 0 {
+		return 0
+	}
+	h := minF32(b.y2, other.y2) - maxF32(b.y1, other.y1)
+	if h <= 0 {
+		return 0
+	}
+	inter := w * h
+	return inter / (b.area + other.area - inter)
+}
+
+// rectangle Rounds box to rectangle which fits into image of given size
+func (b *nmsBox) rectangle(maxwidth, maxheight int) image.Rectangle {
+	return image.Rect(MaxInt(int(b.x1), 0), MaxInt(int(b.y1), 0), MinInt(int(b.x2)+1, maxwidth), MinInt(int(b.y2)+1, maxheight))
+}
+
+// NonMaxSuppression Greedy non-maximum suppression
+/*
+	Detections are ranked by objectness confidence multiplied by class probability (highest first).
+	Detection is kept only if its IoU with every already kept detection of the same class doesn't exceed iouThreshold.
+	Classes are processed independently.
+	Returned detections are sorted by rank in descending order; input slice is not modified.
+*/
+func NonMaxSuppression(detections Detections, iouThreshold float32, options ...NMSOption) Detections {
+	boxes := make([]nmsBox, len(detections))
+	for i, d := range detections {
+		boxes[i] = newNMSBox(float32(d.rect.Min.X), float32(d.rect.Min.Y), float32(d.rect.Max.X), float32(d.rect.Max.Y), d.conf*d.score, d.classIdx, i)
+	}
+	kept := suppress(boxes, iouThreshold, options...)
+	nms := make(Detections, len(kept))
+	for i := range kept {
+		nms[i] = detections[kept[i].idx]
+	}
+	return nms
+}
+
+// suppress Does non-maximum suppression of float boxes (see NonMaxSuppression()). Returned boxes are sorted by rank in descending order
+func suppress(boxes []nmsBox, iouThreshold float32, options ...NMSOption) []nmsBox {
+	opts := nmsOptions{}
+	for _, o := range options {
+		o(&opts)
+	}
+	buckets := map[int][]nmsBox{}
+	for i := range boxes {
+		buckets[boxes[i].classIdx] = append(buckets[boxes[i].classIdx], boxes[i])
+	}
+	kept := make([]nmsBox, 0, len(boxes))
+	for _, classBoxes := range buckets {
+		sortNMSBoxes(classBoxes)
+		// Negative threshold suppresses non-overlapping boxes too, so grid can't be used
+		if opts.grid && iouThreshold >= 0 && len(classBoxes) >= gridNMSMinBoxes {
+			kept = suppressGrid(classBoxes, iouThreshold, kept)
+		} else {
+			kept = suppressGreedy(classBoxes, iouThreshold, kept)
+		}
+	}
+	sortNMSBoxes(kept)
+	return kept
+}
+
+// sortNMSBoxes Sorts boxes by rank in descending order (ties are broken by position in input)
+func sortNMSBoxes(boxes []nmsBox) {
+	sort.Slice(boxes, func(i, j int) bool {
+		if boxes[i].rank == boxes[j].rank {
+			return boxes[i].idx < boxes[j].idx
+		}
+		return boxes[i].rank > boxes[j].rank
+	})
+}
+
+// suppressGreedy Appends boxes which survive suppression to kept. Boxes should be sorted by rank
+func suppressGreedy(boxes []nmsBox, iouThreshold float32, kept []nmsBox) []nmsBox {
+	start := len(kept)
+	for i := range boxes {
+		suppressed := false
+		for j := start; j < len(kept); j++ {
+			if boxes[i].iou(&kept[j]) > iouThreshold {
+				suppressed = true
+				break
+			}
+		}
+		if !suppressed {
+			kept = append(kept, boxes[i])
+		}
+	}
+	return kept
+}
+
+// suppressGrid Does the same as suppressGreedy, but compares box only with kept boxes which share grid cells with it
+/*
+	Cell size equals to average size of boxes. Boxes with non-zero intersection always share at least one cell,
+	so result is exactly the same as for greedy suppression when iouThreshold is not negative.
+*/
+func suppressGrid(boxes []nmsBox, iouThreshold float32, kept []nmsBox) []nmsBox {
+	minX, minY := boxes[0].x1, boxes[0].y1
+	maxX, maxY := boxes[0].x2, boxes[0].y2
+	sumW, sumH := float32(0.0), float32(0.0)
+	for i := range boxes {
+		minX, minY = minF32(minX, boxes[i].x1), minF32(minY, boxes[i].y1)
+		maxX, maxY = maxF32(maxX, boxes[i].x2), maxF32(maxY, boxes[i].y2)
+		sumW += boxes[i].x2 - boxes[i].x1
+		sumH += boxes[i].y2 - boxes[i].y1
+	}
+	cols := gridNMSCells(maxX-minX, sumW/float32(len(boxes)))
+	rows := gridNMSCells(maxY-minY, sumH/float32(len(boxes)))
+	cellW := maxF32((maxX-minX)/float32(cols), 1)
+	cellH := maxF32((maxY-minY)/float32(rows), 1)
+	cell := func(v, origin, size float32, n int) int {
+		c := int((v - origin) / size)
+		if c < 0 {
+			return 0
+		}
+		if c >= n {
+			return n - 1
+		}
+		return c
+	}
+
+	// Every cell holds positions of kept boxes (relative to start) which cover it
+	cells := make([][]int, cols*rows)
+	// stamps Prevents checking the same kept box twice for single candidate
+	stamps := make([]int, 0, len(boxes))
+	start := len(kept)
+	for i := range boxes {
+		c0, c1 := cell(boxes[i].x1, minX, cellW, cols), cell(boxes[i].x2, minX, cellW, cols)
+		r0, r1 := cell(boxes[i].y1, minY, cellH, rows), cell(boxes[i].y2, minY, cellH, rows)
+		suppressed := false
+	search:
+		for r := r0; r <= r1; r++ {
+			for c := c0; c <= c1; c++ {
+				for _, k := range cells[r*cols+c] {
+					if stamps[k] == i+1 {
+						continue
+					}
+					stamps[k] = i + 1
+					if boxes[i].iou(&kept[start+k]) > iouThreshold {
+						suppressed = true
+						break search
+					}
+				}
+			}
+		}
+		if suppressed {
+			continue
+		}
+		k := len(kept) - start
+		kept = append(kept, boxes[i])
+		stamps = append(stamps, 0)
+		for r := r0; r <= r1; r++ {
+			for c := c0; c <= c1; c++ {
+				cells[r*cols+c] = append(cells[r*cols+c], k)
+			}
+		}
+	}
+	return kept
+}
+
+// gridNMSCells Number of grid cells along axis of given extent
+func gridNMSCells(extent, averageSize float32) int {
+	if averageSize < 1 {
+		averageSize = 1
+	}
+	n := int(math.Ceil(float64(extent / averageSize)))
+	if n < 1 {
+		return 1
+	}
+	if n > gridNMSMaxCells {
+		return gridNMSMaxCells
+	}
+	return n
+}
+
+func minF32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxF32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
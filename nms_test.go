@@ -0,0 +1,52 @@
+package yologo
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNonMaxSuppression(t *testing.T) {
+	low := &DetectionRectangle{conf: 0.9, score: 0.3, rect: image.Rect(10, 10, 110, 110), class: "dog", classIdx: 0}
+	high := &DetectionRectangle{conf: 0.9, score: 0.8, rect: image.Rect(15, 15, 115, 115), class: "dog", classIdx: 0}
+	otherClass := &DetectionRectangle{conf: 0.5, score: 0.5, rect: image.Rect(12, 12, 112, 112), class: "cat", classIdx: 1}
+	separate := &DetectionRectangle{conf: 0.6, score: 0.6, rect: image.Rect(200, 200, 250, 250), class: "dog", classIdx: 0}
+
+	nms := NonMaxSuppression(Detections{low, separate, otherClass, high}, 0.45)
+	assert.Equal(t, Detections{high, separate, otherClass}, nms, "Highest ranked box should be kept and result should be sorted by rank")
+
+	// Grid lookup should give exactly the same result as plain greedy suppression
+	for _, classesNum := range []int{1, 80} {
+		dets := benchCandidates(classesNum)
+		for _, iouThreshold := range []float32{0, 0.45, 0.9} {
+			expected := NonMaxSuppression(dets, iouThreshold)
+			assert.Equal(t, expected, NonMaxSuppression(dets, iouThreshold, WithGridNMS()), "Grid NMS differs from greedy one for %d classes and IoU threshold %f", classesNum, iouThreshold)
+		}
+	}
+}
+
+func TestSuppressFloatBoxes(t *testing.T) {
+	// Two boxes of the same class: x, y, h, w, objectness and class probability
+	data := []float32{
+		5, 5, 10, 10, 1, 0.9,
+		9.9, 5, 10, 10, 1, 0.8,
+	}
+	dets, boxes := prepareDetections(data, 0.5, 416, []string{"dog"}, nil, nil)
+	if !assert.Len(t, boxes, 2) {
+		return
+	}
+	assert.InDeltaSlice(t, []float32{4.9, 0, 14.9, 10}, []float32{boxes[1].x1, boxes[1].y1, boxes[1].x2, boxes[1].y2}, 1e-5, "Box should keep float coordinates")
+
+	// IoU of float boxes is 0.34, while IoU of rounded rectangles is 0.47
+	kept := suppress(boxes, 0.4)
+	if !assert.Len(t, kept, 2, "Boxes should be compared before rounding") {
+		return
+	}
+	assert.Equal(t, image.Rect(0, 0, 11, 11), kept[0].rectangle(416, 416))
+	assert.Equal(t, image.Rect(4, 0, 15, 11), kept[1].rectangle(416, 416))
+	for i := range dets {
+		dets[i].rect = boxes[i].rectangle(416, 416)
+	}
+	assert.Len(t, NonMaxSuppression(dets, 0.4), 1, "Rounded rectangles should overlap more than float boxes")
+}
@@ -0,0 +1,256 @@
+package yologo
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/chewxy/math32"
+	"github.com/pkg/errors"
+	"gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// optimizerEps Term which is added to denominator of adaptive optimizers for numerical stability
+const optimizerEps = 1e-8
+
+// Optimizer Solver which keeps its state between steps and allows to change learning rate on the fly
+/*
+	Gradients are zeroed after each step (as gorgonia's solvers do).
+*/
+type Optimizer interface {
+	gorgonia.Solver
+	// LearningRate Returns current learning rate
+	LearningRate() float64
+	// SetLearningRate Changes learning rate for next steps (accumulated state is kept)
+	SetLearningRate(learningRate float64)
+}
+
+// OptimizerOption Option for optimizers constructors
+type OptimizerOption func(*optimizerOptions)
+
+type optimizerOptions struct {
+	weightDecay float32
+	clipNorm    float32
+}
+
+// WithWeightDecay Sets decoupled weight decay: weights are multiplied by (1 - learningRate * decay) on each step
+/*
+	Decay is applied to weights directly instead of adding L2 term to gradients, so it is not scaled by adaptive
+	denominators of Adam and RMSProp (i.e. NewAdam() with this option is AdamW).
+*/
+func WithWeightDecay(decay float64) OptimizerOption {
+	return func(opts *optimizerOptions) {
+		opts.weightDecay = float32(decay)
+	}
+}
+
+// WithGradientClipping Scales gradients down when their global L2 norm (over all nodes of step) exceeds maxNorm
+func WithGradientClipping(maxNorm float64) OptimizerOption {
+	return func(opts *optimizerOptions) {
+		opts.clipNorm = float32(maxNorm)
+	}
+}
+
+// optimizerBase Learning rate, options and per-node state shared by optimizers
+type optimizerBase struct {
+	learningRate float64
+	opts         optimizerOptions
+	// state Buffers of every node of model (in order of nodes), number of buffers depends on optimizer
+	state [][][]float32
+}
+
+func newOptimizerBase(learningRate float64, options []OptimizerOption) optimizerBase {
+	base := optimizerBase{
+		learningRate: learningRate,
+	}
+	for _, o := range options {
+		o(&base.opts)
+	}
+	return base
+}
+
+// LearningRate Returns current learning rate
+func (base *optimizerBase) LearningRate() float64 {
+	return base.learningRate
+}
+
+// SetLearningRate Changes learning rate for next steps (accumulated state is kept)
+func (base *optimizerBase) SetLearningRate(learningRate float64) {
+	base.learningRate = learningRate
+}
+
+// optimizerParam Weights and gradients of single node
+type optimizerParam struct {
+	weights []float32
+	grad    []float32
+	// gradTensor Tensor of gradients which is zeroed after step
+	gradTensor *tensor.Dense
+}
+
+// prepare Extracts weights and gradients of model, allocates state buffers and returns scale of gradients for clipping
+func (base *optimizerBase) prepare(model []gorgonia.ValueGrad, buffers int) ([]optimizerParam, float32, error) {
+	if base.state == nil {
+		base.state = make([][][]float32, len(model))
+	}
+	if len(base.state) != len(model) {
+		return nil, 0, fmt.Errorf("Optimizer has been used for %d nodes, but got %d", len(base.state), len(model))
+	}
+	params := make([]optimizerParam, len(model))
+	sumSquares := float64(0.0)
+	for i, n := range model {
+		weights, ok := n.Value().(*tensor.Dense)
+		if !ok || weights.Dtype() != tensor.Float32 {
+			return nil, 0, fmt.Errorf("Value of node #%d should be float32 tensor", i)
+		}
+		gradValue, err := n.Grad()
+		if err != nil {
+			return nil, 0, errors.Wrap(err, fmt.Sprintf("Can't get gradient of node #%d", i))
+		}
+		grad, ok := gradValue.(*tensor.Dense)
+		if !ok || grad.Dtype() != tensor.Float32 {
+			return nil, 0, fmt.Errorf("Gradient of node #%d should be float32 tensor", i)
+		}
+		params[i] = optimizerParam{
+			weights:    weights.Float32s(),
+			grad:       grad.Float32s(),
+			gradTensor: grad,
+		}
+		if len(params[i].weights) != len(params[i].grad) {
+			return nil, 0, fmt.Errorf("Node #%d has %d weights, but %d gradients", i, len(params[i].weights), len(params[i].grad))
+		}
+		if base.state[i] == nil {
+			base.state[i] = make([][]float32, buffers)
+			for b := range base.state[i] {
+				base.state[i][b] = make([]float32, len(params[i].weights))
+			}
+		}
+		if base.opts.clipNorm > 0 {
+			for _, g := range params[i].grad {
+				sumSquares += float64(g) * float64(g)
+			}
+		}
+	}
+	scale := float32(1.0)
+	if norm := float32(math.Sqrt(sumSquares)); base.opts.clipNorm > 0 && norm > base.opts.clipNorm {
+		scale = base.opts.clipNorm / norm
+	}
+	return params, scale, nil
+}
+
+// finish Applies decoupled weight decay and zeroes gradients
+func (base *optimizerBase) finish(params []optimizerParam) {
+	keep := float32(1.0 - base.learningRate*float64(base.opts.weightDecay))
+	for _, param := range params {
+		if base.opts.weightDecay != 0 {
+			for j := range param.weights {
+				param.weights[j] *= keep
+			}
+		}
+		param.gradTensor.Zero()
+	}
+}
+
+// SGD Stochastic gradient descent with momentum (default optimizer of Darknet)
+type SGD struct {
+	optimizerBase
+	momentum float32
+}
+
+// NewSGD Creates SGD optimizer: velocity = momentum * velocity - learningRate * gradient; weights += velocity
+func NewSGD(learningRate, momentum float64, options ...OptimizerOption) *SGD {
+	return &SGD{
+		optimizerBase: newOptimizerBase(learningRate, options),
+		momentum:      float32(momentum),
+	}
+}
+
+// Step Updates values of nodes by their gradients
+func (opt *SGD) Step(model []gorgonia.ValueGrad) error {
+	params, scale, err := opt.prepare(model, 1)
+	if err != nil {
+		return err
+	}
+	lr := float32(opt.learningRate)
+	for i, param := range params {
+		velocity := opt.state[i][0]
+		for j, g := range param.grad {
+			velocity[j] = opt.momentum*velocity[j] - lr*scale*g
+			param.weights[j] += velocity[j]
+		}
+	}
+	opt.finish(params)
+	return nil
+}
+
+// Adam Adaptive moment estimation (AdamW if weight decay is set, see WithWeightDecay())
+type Adam struct {
+	optimizerBase
+	beta1, beta2 float32
+	// t Number of done steps for bias correction of moments
+	t int
+}
+
+// NewAdam Creates Adam optimizer with given decay rates of the first and the second moments (usually 0.9 and 0.999)
+func NewAdam(learningRate, beta1, beta2 float64, options ...OptimizerOption) *Adam {
+	return &Adam{
+		optimizerBase: newOptimizerBase(learningRate, options),
+		beta1:         float32(beta1),
+		beta2:         float32(beta2),
+	}
+}
+
+// Step Updates values of nodes by their gradients
+func (opt *Adam) Step(model []gorgonia.ValueGrad) error {
+	params, scale, err := opt.prepare(model, 2)
+	if err != nil {
+		return err
+	}
+	opt.t++
+	correction1 := 1 - math32.Pow(opt.beta1, float32(opt.t))
+	correction2 := 1 - math32.Pow(opt.beta2, float32(opt.t))
+	lr := float32(opt.learningRate)
+	for i, param := range params {
+		m, v := opt.state[i][0], opt.state[i][1]
+		for j, g := range param.grad {
+			g *= scale
+			m[j] = opt.beta1*m[j] + (1-opt.beta1)*g
+			v[j] = opt.beta2*v[j] + (1-opt.beta2)*g*g
+			param.weights[j] -= lr * (m[j] / correction1) / (math32.Sqrt(v[j]/correction2) + optimizerEps)
+		}
+	}
+	opt.finish(params)
+	return nil
+}
+
+// RMSProp Root mean square propagation
+type RMSProp struct {
+	optimizerBase
+	decay float32
+}
+
+// NewRMSProp Creates RMSProp optimizer with given decay rate of mean square of gradients (usually 0.999)
+func NewRMSProp(learningRate, decay float64, options ...OptimizerOption) *RMSProp {
+	return &RMSProp{
+		optimizerBase: newOptimizerBase(learningRate, options),
+		decay:         float32(decay),
+	}
+}
+
+// Step Updates values of nodes by their gradients
+func (opt *RMSProp) Step(model []gorgonia.ValueGrad) error {
+	params, scale, err := opt.prepare(model, 1)
+	if err != nil {
+		return err
+	}
+	lr := float32(opt.learningRate)
+	for i, param := range params {
+		cache := opt.state[i][0]
+		for j, g := range param.grad {
+			g *= scale
+			cache[j] = opt.decay*cache[j] + (1-opt.decay)*g*g
+			param.weights[j] -= lr * g / math32.Sqrt(cache[j]+optimizerEps)
+		}
+	}
+	opt.finish(params)
+	return nil
+}
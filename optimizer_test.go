@@ -0,0 +1,71 @@
+package yologo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// testValueGrad Node with fixed value and gradient
+type testValueGrad struct {
+	value, grad *tensor.Dense
+}
+
+func (vg *testValueGrad) Value() gorgonia.Value {
+	return vg.value
+}
+
+func (vg *testValueGrad) Grad() (gorgonia.Value, error) {
+	return vg.grad, nil
+}
+
+func newTestValueGrad(value, grad []float32) *testValueGrad {
+	return &testValueGrad{
+		value: tensor.New(tensor.WithShape(len(value)), tensor.WithBacking(value)),
+		grad:  tensor.New(tensor.WithShape(len(grad)), tensor.WithBacking(grad)),
+	}
+}
+
+func TestOptimizers(t *testing.T) {
+	// SGD with momentum: velocity is accumulated and kept after change of learning rate
+	vg := newTestValueGrad([]float32{1, -1}, []float32{1, 2})
+	sgd := NewSGD(0.1, 0.9)
+	assert.NoError(t, sgd.Step([]gorgonia.ValueGrad{vg}))
+	assert.InDeltaSlice(t, []float32{0.9, -1.2}, vg.value.Float32s(), 1e-6)
+	// Gradients are zeroed after step
+	assert.Equal(t, []float32{0, 0}, vg.grad.Float32s())
+	copy(vg.grad.Float32s(), []float32{1, 2})
+	sgd.SetLearningRate(0.01)
+	assert.Equal(t, 0.01, sgd.LearningRate())
+	assert.NoError(t, sgd.Step([]gorgonia.ValueGrad{vg}))
+	assert.InDeltaSlice(t, []float32{0.9 - 0.09 - 0.01, -1.2 - 0.18 - 0.02}, vg.value.Float32s(), 1e-6)
+
+	// Adam: the first step moves every weight by learning rate due to bias correction
+	vg = newTestValueGrad([]float32{1, -1}, []float32{0.5, -3})
+	adam := NewAdam(0.01, 0.9, 0.999)
+	assert.NoError(t, adam.Step([]gorgonia.ValueGrad{vg}))
+	assert.InDeltaSlice(t, []float32{0.99, -0.99}, vg.value.Float32s(), 1e-5)
+
+	// AdamW: decoupled weight decay doesn't depend on gradients
+	vg = newTestValueGrad([]float32{2, -2}, []float32{0, 0})
+	adamW := NewAdam(0.1, 0.9, 0.999, WithWeightDecay(0.5))
+	assert.NoError(t, adamW.Step([]gorgonia.ValueGrad{vg}))
+	assert.InDeltaSlice(t, []float32{1.9, -1.9}, vg.value.Float32s(), 1e-6)
+
+	// Clipping by global norm: gradient (3, 4) has norm 5 and is scaled down to norm 1
+	vg = newTestValueGrad([]float32{0, 0}, []float32{3, 4})
+	clipped := NewSGD(1, 0, WithGradientClipping(1))
+	assert.NoError(t, clipped.Step([]gorgonia.ValueGrad{vg}))
+	assert.InDeltaSlice(t, []float32{-0.6, -0.8}, vg.value.Float32s(), 1e-6)
+
+	// RMSProp: the first step is learning rate / sqrt(1 - decay) for any gradient
+	vg = newTestValueGrad([]float32{0, 0}, []float32{2, -0.1})
+	rmsProp := NewRMSProp(0.001, 0.99)
+	assert.NoError(t, rmsProp.Step([]gorgonia.ValueGrad{vg}))
+	assert.InDeltaSlice(t, []float32{-0.01, 0.01}, vg.value.Float32s(), 1e-5)
+
+	// Optimizer keeps state per node, so number of nodes can't change
+	assert.Error(t, rmsProp.Step([]gorgonia.ValueGrad{vg, vg}))
+}
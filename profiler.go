@@ -0,0 +1,198 @@
+package yologo
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"gorgonia.org/tensor"
+)
+
+// Profiler Records wall time and heap allocations of every layer for each forward pass
+/*
+	Usage:
+	1. Create network via NewYoloV3(..., WithProfiler(profiler));
+	2. Run forward passes;
+	3. Inspect profiler.Runs() or write profiler.WriteChromeTrace() output and open it in chrome://tracing (or https://ui.perfetto.dev).
+	Layers are marked the same way as for LayerTimer. Allocations are read via runtime.ReadMemStats() on every mark,
+	which stops the world for a short time, so profiled passes are slower than usual ones.
+	Every forward pass is kept in memory until Reset() is called.
+*/
+type Profiler struct {
+	mu      sync.Mutex
+	layers  []string
+	runs    []ProfiledRun
+	last    time.Time
+	lastMem runtime.MemStats
+}
+
+// ProfiledRun Profile of single forward pass
+type ProfiledRun struct {
+	Start    time.Time
+	Duration time.Duration
+	Layers   []LayerProfile
+}
+
+// LayerProfile Profile of single layer in forward pass
+type LayerProfile struct {
+	Index    int
+	Layer    string
+	Start    time.Time
+	Duration time.Duration
+	// AllocBytes Bytes allocated on heap
+	AllocBytes uint64
+	// Mallocs Number of heap objects allocated
+	Mallocs uint64
+}
+
+// NewProfiler Creates new profiler
+func NewProfiler() *Profiler {
+	return &Profiler{}
+}
+
+// Runs Returns profiles of recorded forward passes
+func (p *Profiler) Runs() []ProfiledRun {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	runs := make([]ProfiledRun, len(p.runs))
+	for i := range p.runs {
+		runs[i] = p.runs[i]
+		runs[i].Layers = append([]LayerProfile{}, p.runs[i].Layers...)
+	}
+	return runs
+}
+
+// Reset Drops recorded forward passes
+func (p *Profiler) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.runs = nil
+}
+
+// chromeTraceEvent Complete event of Chrome Trace Event Format
+type chromeTraceEvent struct {
+	Name      string                 `json:"name"`
+	Category  string                 `json:"cat"`
+	Phase     string                 `json:"ph"`
+	Timestamp float64                `json:"ts"`
+	Duration  float64                `json:"dur"`
+	PID       int                    `json:"pid"`
+	TID       int                    `json:"tid"`
+	Args      map[string]interface{} `json:"args,omitempty"`
+}
+
+// WriteChromeTrace Writes recorded forward passes in Chrome Trace Event Format (JSON)
+/*
+	Every forward pass and every layer is represented by complete ("X") event; allocations are stored in events' arguments.
+*/
+func (p *Profiler) WriteChromeTrace(w io.Writer) error {
+	runs := p.Runs()
+	events := []chromeTraceEvent{}
+	if len(runs) > 0 {
+		origin := runs[0].Start
+		micros := func(d time.Duration) float64 {
+			return float64(d) / float64(time.Microsecond)
+		}
+		for i, run := range runs {
+			events = append(events, chromeTraceEvent{
+				Name:      "forward",
+				Category:  "forward",
+				Phase:     "X",
+				Timestamp: micros(run.Start.Sub(origin)),
+				Duration:  micros(run.Duration),
+				PID:       1,
+				TID:       1,
+				Args:      map[string]interface{}{"run": i},
+			})
+			for _, layer := range run.Layers {
+				events = append(events, chromeTraceEvent{
+					Name:      layer.Layer,
+					Category:  "layer",
+					Phase:     "X",
+					Timestamp: micros(layer.Start.Sub(origin)),
+					Duration:  micros(layer.Duration),
+					PID:       1,
+					TID:       1,
+					Args: map[string]interface{}{
+						"index":       layer.Index,
+						"alloc_bytes": layer.AllocBytes,
+						"mallocs":     layer.Mallocs,
+					},
+				})
+			}
+		}
+	}
+	trace := struct {
+		TraceEvents     []chromeTraceEvent `json:"traceEvents"`
+		DisplayTimeUnit string             `json:"displayTimeUnit"`
+	}{
+		TraceEvents:     events,
+		DisplayTimeUnit: "ms",
+	}
+	return json.NewEncoder(w).Encode(trace)
+}
+
+// WriteChromeTraceFile Writes recorded forward passes in Chrome Trace Event Format to file (see WriteChromeTrace())
+func (p *Profiler) WriteChromeTraceFile(fname string) error {
+	file, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	err = p.WriteChromeTrace(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// register Adds layer description
+func (p *Profiler) register(layerIndex int, layer string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(p.layers) <= layerIndex {
+		p.layers = append(p.layers, "")
+	}
+	p.layers[layerIndex] = layer
+}
+
+// startObserver Returns hook which starts new forward pass (network input is ready)
+func (p *Profiler) startObserver() func(tensor.Tensor) {
+	return func(t tensor.Tensor) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		runtime.ReadMemStats(&p.lastMem)
+		p.last = time.Now()
+		p.runs = append(p.runs, ProfiledRun{Start: p.last})
+	}
+}
+
+// observer Returns hook which records given layer into current forward pass
+func (p *Profiler) observer(layerIndex int) func(tensor.Tensor) {
+	return func(t tensor.Tensor) {
+		now := time.Now()
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if len(p.runs) == 0 {
+			return
+		}
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		run := &p.runs[len(p.runs)-1]
+		run.Layers = append(run.Layers, LayerProfile{
+			Index:      layerIndex,
+			Layer:      p.layers[layerIndex],
+			Start:      p.last,
+			Duration:   now.Sub(p.last),
+			AllocBytes: mem.TotalAlloc - p.lastMem.TotalAlloc,
+			Mallocs:    mem.Mallocs - p.lastMem.Mallocs,
+		})
+		run.Duration = now.Sub(run.Start)
+		p.lastMem = mem
+		// Time spent on reading of memory statistics is not attributed to the next layer
+		p.last = time.Now()
+	}
+}
@@ -0,0 +1,100 @@
+package yologo
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+func TestProfilerChromeTrace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yolo_profiler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	weightsFile := filepath.Join(dir, "micro.weights")
+	writeTestWeights(t, "./test_network_data/yolov3-micro.cfg", weightsFile, 1)
+	profiler := NewProfiler()
+	timer := NewLayerTimer()
+	// Both observers are attached to the same layers
+	model := newMicroModel(t, weightsFile, WithProfiler(profiler), WithLayerTimer(timer))
+
+	runs := 2
+	rng := rand.New(rand.NewSource(1))
+	vm := gorgonia.NewTapeMachine(model.g)
+	defer vm.Close()
+	for i := 0; i < runs; i++ {
+		err = gorgonia.Let(model.input, tensor.New(tensor.WithShape(1, 3, 32, 32), tensor.WithBacking(randomFloat32s(rng, 3*32*32))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = vm.RunAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		vm.Reset()
+	}
+	assert.Equal(t, runs, timer.Runs())
+	assert.Len(t, profiler.Runs(), runs)
+
+	buf := &bytes.Buffer{}
+	err = profiler.WriteChromeTrace(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trace := struct {
+		TraceEvents     []map[string]interface{} `json:"traceEvents"`
+		DisplayTimeUnit string                   `json:"displayTimeUnit"`
+	}{}
+	err = json.Unmarshal(buf.Bytes(), &trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "ms", trace.DisplayTimeUnit)
+
+	// Every pass is "forward" event followed by events of its layers in order of network
+	layersNum := len(model.layersInfo)
+	if !assert.Len(t, trace.TraceEvents, runs*(1+layersNum)) {
+		return
+	}
+	prevStart := -1.0
+	for i, event := range trace.TraceEvents {
+		for _, field := range []string{"name", "cat", "ph", "ts", "dur", "pid", "tid", "args"} {
+			assert.Contains(t, event, field, "Event #%d has no field '%s'", i, field)
+		}
+		assert.Equal(t, "X", event["ph"], "Event #%d should be complete event", i)
+		start, ok := event["ts"].(float64)
+		if !assert.True(t, ok, "Start time of event #%d should be number", i) {
+			continue
+		}
+		assert.GreaterOrEqual(t, start, prevStart, "Start time of event #%d goes back", i)
+		prevStart = start
+		duration, _ := event["dur"].(float64)
+		assert.GreaterOrEqual(t, duration, 0.0, "Negative duration of event #%d", i)
+		args, _ := event["args"].(map[string]interface{})
+		layer := i%(1+layersNum) - 1
+		if layer < 0 {
+			assert.Equal(t, "forward", event["name"])
+			assert.Equal(t, "forward", event["cat"])
+			assert.Equal(t, float64(i/(1+layersNum)), args["run"])
+			continue
+		}
+		assert.Equal(t, model.layersInfo[layer], event["name"])
+		assert.Equal(t, "layer", event["cat"])
+		assert.Equal(t, float64(layer), args["index"])
+		assert.Contains(t, args, "alloc_bytes")
+		assert.Contains(t, args, "mallocs")
+	}
+	assert.Equal(t, 0.0, trace.TraceEvents[0]["ts"], "Trace should start at the first pass")
+
+	profiler.Reset()
+	assert.Empty(t, profiler.Runs())
+}
@@ -0,0 +1,312 @@
+package yologo
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chewxy/math32"
+)
+
+// PruningCriterion Criterion for ranking of convolution filters in channel pruning
+type PruningCriterion int
+
+const (
+	// PruneByL1Norm Filters are ranked by L1 norm of their kernels
+	PruneByL1Norm = PruningCriterion(iota)
+	// PruneByGamma Filters are ranked by magnitude of batch normalization scale (gamma). Layers without batch normalization are not pruned
+	PruneByGamma
+)
+
+// ParsePruningCriterion Returns criterion by its name: "l1" or "gamma"
+func ParsePruningCriterion(name string) (PruningCriterion, error) {
+	switch strings.ToLower(name) {
+	case "l1":
+		return PruneByL1Norm, nil
+	case "gamma":
+		return PruneByGamma, nil
+	default:
+		return 0, fmt.Errorf("Unknown pruning criterion '%s'", name)
+	}
+}
+
+// prunedConv Convolution layer as it is stored in darknet weights file
+type prunedConv struct {
+	blockIdx       int
+	filters        int
+	kernelSize     int
+	batchNormalize bool
+	// offset Position of layer's parameters in weights
+	offset int
+	// inputIDs and outputIDs Identifiers of input and output channels (see channelGraph)
+	inputIDs  []int
+	outputIDs []int
+}
+
+// channelGraph Disjoint sets of channels which must be pruned together
+/*
+	Every output channel of convolution layer (and every channel of image) gets its own identifier.
+	Layers without parameters pass identifiers through (route layer concatenates them),
+	while shortcut layer merges channels of both inputs, since they are summed up element-wise.
+*/
+type channelGraph struct {
+	parent    []int
+	owner     []int
+	protected []bool
+}
+
+func (cg *channelGraph) newChannels(n, owner int) []int {
+	ids := make([]int, n)
+	for i := range ids {
+		ids[i] = len(cg.parent)
+		cg.parent = append(cg.parent, ids[i])
+		cg.owner = append(cg.owner, owner)
+		cg.protected = append(cg.protected, false)
+	}
+	return ids
+}
+
+func (cg *channelGraph) find(id int) int {
+	for cg.parent[id] != id {
+		cg.parent[id] = cg.parent[cg.parent[id]]
+		id = cg.parent[id]
+	}
+	return id
+}
+
+func (cg *channelGraph) union(a, b int) {
+	ra, rb := cg.find(a), cg.find(b)
+	if ra != rb {
+		cg.parent[rb] = ra
+	}
+}
+
+// PruneChannels Removes lowest-ranked output channels of convolution layers
+/*
+	blocks - darknet configuration (see ParseConfiguration()), weights - raw darknet weights (see ParseWeights()).
+	Channels which are summed up by shortcut layers are ranked and removed together; route layers just pass removal to consumers.
+	Convolution layers which feed YOLO layers keep all of their channels.
+	In every group of layers the fraction 'ratio' of channels is removed (at least one channel is kept).
+	Returns new configuration and weights; pruned network usually needs fine-tuning to restore accuracy.
+*/
+func PruneChannels(blocks []map[string]string, weights []float32, criterion PruningCriterion, ratio float32) ([]map[string]string, []float32, error) {
+	if ratio < 0 || ratio >= 1 {
+		return nil, nil, fmt.Errorf("Pruning ratio should be in range [0; 1), but got %f", ratio)
+	}
+	if len(blocks) < 2 {
+		return nil, nil, fmt.Errorf("Configuration should contain network parameters and at least one layer")
+	}
+	if len(weights) < weightsHeaderSize {
+		return nil, nil, fmt.Errorf("Weights should contain header of %d values, but got %d values only", weightsHeaderSize, len(weights))
+	}
+
+	cg := &channelGraph{}
+	channels, err := InputChannels(blocks[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	convs, err := traceChannels(cg, blocks[1:], channels, len(weights))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Rank channels and gather groups of channels which are removed together
+	scores := make([]float32, len(cg.parent))
+	for _, conv := range convs {
+		for c, id := range conv.outputIDs {
+			if criterion == PruneByGamma && !conv.batchNormalize {
+				cg.protected[id] = true
+				continue
+			}
+			scores[id] = conv.score(weights, c, criterion)
+		}
+	}
+	groupScores := map[int]float32{}
+	groupOwners := map[int][]int{}
+	groupProtected := map[int]bool{}
+	for id := range cg.parent {
+		root := cg.find(id)
+		groupScores[root] += scores[id]
+		groupOwners[root] = append(groupOwners[root], cg.owner[id])
+		groupProtected[root] = groupProtected[root] || cg.protected[id]
+	}
+	// Groups of channels which are produced by the same set of layers are ranked against each other
+	sets := map[string][]int{}
+	for root, owners := range groupOwners {
+		if groupProtected[root] {
+			continue
+		}
+		sort.Ints(owners)
+		key := fmt.Sprint(owners)
+		sets[key] = append(sets[key], root)
+	}
+	removed := map[int]bool{}
+	for _, roots := range sets {
+		sort.Slice(roots, func(i, j int) bool {
+			if groupScores[roots[i]] == groupScores[roots[j]] {
+				return roots[i] < roots[j]
+			}
+			return groupScores[roots[i]] < groupScores[roots[j]]
+		})
+		n := int(ratio * float32(len(roots)))
+		if n >= len(roots) {
+			n = len(roots) - 1
+		}
+		for _, root := range roots[:n] {
+			removed[root] = true
+		}
+	}
+
+	// Copy kept parameters
+	prunedBlocks := make([]map[string]string, len(blocks))
+	for i := range blocks {
+		prunedBlocks[i] = make(map[string]string, len(blocks[i]))
+		for k, v := range blocks[i] {
+			prunedBlocks[i][k] = v
+		}
+	}
+	prunedWeights := make([]float32, 0, len(weights))
+	prunedWeights = append(prunedWeights, weights[:weightsHeaderSize]...)
+	for _, conv := range convs {
+		keepOut := keptChannels(cg, conv.outputIDs, removed)
+		keepIn := keptChannels(cg, conv.inputIDs, removed)
+		vectors := 1
+		if conv.batchNormalize {
+			vectors = 4 // biases, gammas, means, variances
+		}
+		for v := 0; v < vectors; v++ {
+			for _, c := range keepOut {
+				prunedWeights = append(prunedWeights, weights[conv.offset+v*conv.filters+c])
+			}
+		}
+		kernels := weights[conv.offset+vectors*conv.filters:]
+		kernelArea := conv.kernelSize * conv.kernelSize
+		for _, o := range keepOut {
+			for _, c := range keepIn {
+				start := (o*len(conv.inputIDs) + c) * kernelArea
+				prunedWeights = append(prunedWeights, kernels[start:start+kernelArea]...)
+			}
+		}
+		prunedBlocks[conv.blockIdx+1]["filters"] = strconv.Itoa(len(keepOut))
+	}
+	return prunedBlocks, prunedWeights, nil
+}
+
+// traceChannels Assigns identifiers to channels of every layer and locates parameters of convolution layers in weights
+func traceChannels(cg *channelGraph, blocks []map[string]string, channels, weightsSize int) ([]*prunedConv, error) {
+	convs := []*prunedConv{}
+	outputs := make([][]int, len(blocks))
+	// Channels of image are never removed
+	imageIDs := cg.newChannels(channels, -1)
+	for i := range imageIDs {
+		cg.protected[imageIDs[i]] = true
+	}
+	lastIdx := weightsHeaderSize
+	for i, block := range blocks {
+		prev := imageIDs
+		if i > 0 {
+			prev = outputs[i-1]
+		}
+		switch block["type"] {
+		case "convolutional":
+			filters, err := strconv.Atoi(block["filters"])
+			if err != nil {
+				return nil, fmt.Errorf("Wrong or empty 'filters' parameter for convolution layer #%d", i)
+			}
+			kernelSize, err := strconv.Atoi(block["size"])
+			if err != nil {
+				return nil, fmt.Errorf("Wrong or empty 'size' parameter for convolution layer #%d", i)
+			}
+			batchNormalize, err := strconv.Atoi(block["batch_normalize"])
+			conv := &prunedConv{
+				blockIdx:       i,
+				filters:        filters,
+				kernelSize:     kernelSize,
+				batchNormalize: err == nil && batchNormalize > 0,
+				offset:         lastIdx,
+				inputIDs:       prev,
+				outputIDs:      cg.newChannels(filters, i),
+			}
+			vectors := 1
+			if conv.batchNormalize {
+				vectors = 4
+			}
+			lastIdx += vectors*filters + filters*len(prev)*kernelSize*kernelSize
+			if lastIdx > weightsSize {
+				return nil, fmt.Errorf("Weights are too short for convolution layer #%d", i)
+			}
+			convs = append(convs, conv)
+			outputs[i] = conv.outputIDs
+		case "upsample", "maxpool":
+			outputs[i] = prev
+		case "route":
+			ids := []int{}
+			for _, s := range strings.Split(block["layers"], ",") {
+				idx, err := strconv.Atoi(strings.TrimSpace(s))
+				if err != nil {
+					return nil, fmt.Errorf("Each element of 'layers' parameter for route layer #%d should be an integer", i)
+				}
+				if idx < 0 {
+					idx += i
+				}
+				if idx < 0 || idx >= i {
+					return nil, fmt.Errorf("Route layer #%d refers to wrong layer %d", i, idx)
+				}
+				ids = append(ids, outputs[idx]...)
+			}
+			outputs[i] = ids
+		case "shortcut":
+			from, err := strconv.Atoi(block["from"])
+			if err != nil || i+from < 0 || i+from >= i {
+				return nil, fmt.Errorf("Wrong or empty 'from' parameter for shortcut layer #%d", i)
+			}
+			other := outputs[i+from]
+			if len(other) != len(prev) {
+				return nil, fmt.Errorf("Shortcut layer #%d sums up %d and %d channels", i, len(prev), len(other))
+			}
+			for c := range prev {
+				cg.union(prev[c], other[c])
+			}
+			outputs[i] = prev
+		case "yolo":
+			// Number of channels is defined by anchors and classes
+			for _, id := range prev {
+				cg.protected[id] = true
+			}
+			outputs[i] = prev
+		default:
+			return nil, fmt.Errorf("Layer #%d of type '%s' is not supported by pruning", i, block["type"])
+		}
+	}
+	return convs, nil
+}
+
+// score Importance of single filter of convolution layer
+func (conv *prunedConv) score(weights []float32, filter int, criterion PruningCriterion) float32 {
+	if criterion == PruneByGamma {
+		return math32.Abs(weights[conv.offset+conv.filters+filter])
+	}
+	vectors := 1
+	if conv.batchNormalize {
+		vectors = 4
+	}
+	filterSize := len(conv.inputIDs) * conv.kernelSize * conv.kernelSize
+	start := conv.offset + vectors*conv.filters + filter*filterSize
+	l1 := float32(0.0)
+	for _, w := range weights[start : start+filterSize] {
+		l1 += math32.Abs(w)
+	}
+	return l1
+}
+
+// keptChannels Returns positions of channels which are not removed
+func keptChannels(cg *channelGraph, ids []int, removed map[int]bool) []int {
+	kept := make([]int, 0, len(ids))
+	for c, id := range ids {
+		if !removed[cg.find(id)] {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,115 @@
+package yologo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+func TestPruneChannels(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yolo_pruning")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfgFile := "./test_network_data/yolov3-micro-residual.cfg"
+	weightsFile := filepath.Join(dir, "residual.weights")
+	writeTestWeights(t, cfgFile, weightsFile, 1)
+	blocks, err := ParseConfiguration(cfgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weights, err := ParseWeights(weightsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = PruneChannels(blocks, weights, PruneByL1Norm, 1)
+	assert.Error(t, err, "Ratio 1 should be rejected")
+
+	for _, criterion := range []PruningCriterion{PruneByL1Norm, PruneByGamma} {
+		prunedBlocks, prunedWeights, err := PruneChannels(blocks, weights, criterion, 0.5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Blocks: [net], conv (8), conv (4), conv (8), shortcut, maxpool, conv (16), upsample, route, maxpool, conv (21), yolo
+		filters := map[int]string{}
+		for i, block := range prunedBlocks {
+			if block["type"] == "convolutional" {
+				filters[i] = block["filters"]
+			}
+		}
+		// Layers which are summed up by shortcut keep the same channels, layer before YOLO keeps all channels
+		assert.Equal(t, map[int]string{1: "4", 2: "2", 3: "4", 6: "8", 10: "21"}, filters, "Wrong filters for criterion %d", criterion)
+		assert.Equal(t, prunedBlocks[1]["filters"], prunedBlocks[3]["filters"], "Layers tied by shortcut should keep equal number of channels")
+		assert.Equal(t, "8", blocks[1]["filters"], "Source configuration should not be changed")
+
+		// Batch normalization: 4 vectors per filter; kernels: filters * inputs * size^2
+		expectedSize := weightsHeaderSize +
+			4*4 + 4*3*9 + // conv #0: 3 -> 4
+			4*2 + 2*4*1 + // conv #1: 4 -> 2
+			4*4 + 4*2*9 + // conv #2: 2 -> 4
+			4*8 + 8*4*9 + // conv #5: 4 (shortcut) -> 8
+			21 + 21*(8+4)*1 // conv #9: 8 (upsampled conv #5) + 4 (shortcut) -> 21 without batch normalization
+		if !assert.Len(t, prunedWeights, expectedSize, "Wrong size of pruned weights for criterion %d", criterion) {
+			continue
+		}
+
+		prunedCfg := filepath.Join(dir, "pruned.cfg")
+		err = WriteConfiguration(prunedCfg, prunedBlocks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prunedWeightsFile := filepath.Join(dir, "pruned.weights")
+		err = WriteWeights(prunedWeightsFile, prunedWeights, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g := gorgonia.NewGraph()
+		input := gorgonia.NewTensor(g, tensor.Float32, 4, gorgonia.WithShape(1, 3, 32, 32), gorgonia.WithName("input"))
+		_, err = NewYoloV3(g, input, 2, 3, 0.1, prunedCfg, prunedWeightsFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vm := gorgonia.NewTapeMachine(g)
+		err = gorgonia.Let(input, tensor.New(tensor.WithShape(1, 3, 32, 32), tensor.WithBacking(make([]float32, 3*32*32))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.NoError(t, vm.RunAll(), "Pruned network should run")
+		vm.Close()
+	}
+}
+
+func TestPruneChannelsKeepsStrongest(t *testing.T) {
+	cfgFile := "./test_network_data/yolov3-micro.cfg"
+	blocks, err := ParseConfiguration(cfgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "yolo_pruning")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	weightsFile := filepath.Join(dir, "micro.weights")
+	writeTestWeights(t, cfgFile, weightsFile, 1)
+	weights, err := ParseWeights(weightsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Gammas of the first layer (8 filters, after biases): the odd filters are the strongest ones
+	for f := 0; f < 8; f++ {
+		weights[weightsHeaderSize+8+f] = float32(f%2) + 0.01*float32(f)
+	}
+	_, prunedWeights, err := PruneChannels(blocks, weights, PruneByGamma, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []float32{1.01, 1.03, 1.05, 1.07}, prunedWeights[weightsHeaderSize+4:weightsHeaderSize+8])
+}
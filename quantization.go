@@ -0,0 +1,166 @@
+package yologo
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/chewxy/math32"
+	"github.com/pkg/errors"
+	"gorgonia.org/tensor"
+)
+
+const int8Range = 127
+
+// Calibrator Collects maximum absolute values of convolution layers inputs during forward passes of float32 network
+/*
+	Usage:
+	1. Create network via NewYoloV3(..., WithCalibrator(calibrator));
+	2. Run forward passes on small set of representative images (e.g. via Detector.DetectFile());
+	3. Call calibrator.Quantization(network) and pass result to NewYoloV3(..., WithQuantization(quantization)).
+*/
+type Calibrator struct {
+	mu     sync.Mutex
+	maxAbs map[int]float32
+	passes map[int]int
+}
+
+// NewCalibrator Creates new calibrator
+func NewCalibrator() *Calibrator {
+	return &Calibrator{
+		maxAbs: make(map[int]float32),
+		passes: make(map[int]int),
+	}
+}
+
+// observer Returns hook which updates range for given convolution layer
+func (c *Calibrator) observer(layerIndex int) func(tensor.Tensor) {
+	return func(t tensor.Tensor) {
+		data, ok := t.Data().([]float32)
+		if !ok {
+			return
+		}
+		maxAbs := float32(0.0)
+		for i := range data {
+			if v := math32.Abs(data[i]); v > maxAbs {
+				maxAbs = v
+			}
+		}
+		c.mu.Lock()
+		if maxAbs > c.maxAbs[layerIndex] {
+			c.maxAbs[layerIndex] = maxAbs
+		}
+		c.passes[layerIndex]++
+		c.mu.Unlock()
+	}
+}
+
+// Quantization Prepares int8 quantization parameters for every calibrated convolution layer of network
+/*
+	Weights are quantized symmetrically per output channel, inputs - symmetrically per layer with calibrated range.
+*/
+func (c *Calibrator) Quantization(net *YOLOv3) (*Quantization, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	quantization := &Quantization{
+		layers: make(map[int]*convQuantization),
+	}
+	convs := net.convLayers()
+	for i := range convs {
+		conv := convs[i]
+		if c.passes[conv.layerIndex] == 0 {
+			return nil, fmt.Errorf("Convolution layer #%d has not been calibrated. Make sure that network has been created with WithCalibrator() option and forward pass has been done", conv.layerIndex)
+		}
+		inputScale := c.maxAbs[conv.layerIndex] / int8Range
+		if inputScale == 0 {
+			inputScale = 1.0
+		}
+		kernels, ok := conv.convNode.Value().Data().([]float32)
+		if !ok {
+			return nil, fmt.Errorf("Kernels of convolution layer #%d should be type of []float32", conv.layerIndex)
+		}
+		quantization.layers[conv.layerIndex] = &convQuantization{
+			inputScale:   inputScale,
+			weightScales: perChannelScales(kernels, conv.filters),
+		}
+	}
+	return quantization, nil
+}
+
+// Quantization Parameters of int8 quantization for convolution layers
+type Quantization struct {
+	layers map[int]*convQuantization
+}
+
+type convQuantization struct {
+	inputScale   float32
+	weightScales []float32
+}
+
+// perChannelScales Returns symmetric int8 scale for each of output channels
+func perChannelScales(kernels []float32, filters int) []float32 {
+	scales := make([]float32, filters)
+	channelSize := len(kernels) / filters
+	for f := 0; f < filters; f++ {
+		maxAbs := float32(0.0)
+		for _, w := range kernels[f*channelSize : (f+1)*channelSize] {
+			if v := math32.Abs(w); v > maxAbs {
+				maxAbs = v
+			}
+		}
+		scales[f] = maxAbs / int8Range
+		if scales[f] == 0 {
+			scales[f] = 1.0
+		}
+	}
+	return scales
+}
+
+// quantizeF32 Rounds value to int8 with given scale
+func quantizeF32(v, scale float32) int8 {
+	q := float32(math.Round(float64(v / scale)))
+	if q > int8Range {
+		return int8Range
+	}
+	if q < -int8Range {
+		return -int8Range
+	}
+	return int8(q)
+}
+
+// QuantizationReport Comparison of float32 and int8 networks on the same labeled data
+type QuantizationReport struct {
+	Float32 *EvaluationResult
+	Int8    *EvaluationResult
+	// MAPDelta Difference between int8 and float32 mAP (negative value means accuracy loss)
+	MAPDelta float32
+	// Speedup Ratio of float32 average inference time to int8 one
+	Speedup float64
+}
+
+func (report *QuantizationReport) String() string {
+	return fmt.Sprintf("Quantization report:\n\tfloat32: mAP = %f, inference = %v\n\tint8: mAP = %f, inference = %v\n\tmAP delta = %f, speedup = %.2fx",
+		report.Float32.MAP, report.Float32.InferenceTime, report.Int8.MAP, report.Int8.InferenceTime, report.MAPDelta, report.Speedup,
+	)
+}
+
+// CompareQuantization Evaluates float32 and int8 detectors on labeled folder and reports accuracy and latency difference
+func CompareQuantization(float32Detector, int8Detector *Detector, dir string, iouThreshold float32) (*QuantizationReport, error) {
+	float32Result, err := EvaluateFolder(float32Detector, dir, iouThreshold)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't evaluate float32 detector")
+	}
+	int8Result, err := EvaluateFolder(int8Detector, dir, iouThreshold)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't evaluate int8 detector")
+	}
+	report := &QuantizationReport{
+		Float32:  float32Result,
+		Int8:     int8Result,
+		MAPDelta: int8Result.MAP - float32Result.MAP,
+	}
+	if int8Result.InferenceTime > 0 {
+		report.Speedup = float64(float32Result.InferenceTime) / float64(int8Result.InferenceTime)
+	}
+	return report, nil
+}
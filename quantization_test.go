@@ -0,0 +1,173 @@
+package yologo
+
+import (
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// randomFloat32s Returns values uniformly distributed in range [-1; 1)
+func randomFloat32s(rng *rand.Rand, n int) []float32 {
+	values := make([]float32, n)
+	for i := range values {
+		values[i] = 2*rng.Float32() - 1
+	}
+	return values
+}
+
+// maxAbsFloat32 Returns maximum absolute value of slice
+func maxAbsFloat32(values []float32) float32 {
+	maxAbs := float32(0.0)
+	for _, v := range values {
+		if a := float32(math.Abs(float64(v))); a > maxAbs {
+			maxAbs = a
+		}
+	}
+	return maxAbs
+}
+
+func TestQuantizedConvOp(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	batchSize, channels, size, filters, kernelSize := 2, 3, 9, 5, 3
+	inputData := randomFloat32s(rng, batchSize*channels*size*size)
+	kernels := randomFloat32s(rng, filters*channels*kernelSize*kernelSize)
+	biases := randomFloat32s(rng, filters)
+
+	for _, stride := range []int{1, 2} {
+		for _, activation := range []string{"linear", "leaky"} {
+			// Float32 reference: convolution of gorgonia, biases and activation
+			g := gorgonia.NewGraph()
+			input := gorgonia.NewTensor(g, tensor.Float32, 4, gorgonia.WithShape(batchSize, channels, size, size), gorgonia.WithName("input"))
+			kernelNode := gorgonia.NewTensor(g, tensor.Float32, 4, gorgonia.WithShape(filters, channels, kernelSize, kernelSize), gorgonia.WithName("kernels"),
+				gorgonia.WithValue(tensor.New(tensor.WithShape(filters, channels, kernelSize, kernelSize), tensor.WithBacking(append([]float32{}, kernels...)))),
+			)
+			convOut, err := gorgonia.Conv2d(input, kernelNode, tensor.Shape{kernelSize, kernelSize}, []int{1, 1}, []int{stride, stride}, []int{1, 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			vm := gorgonia.NewTapeMachine(g)
+			err = gorgonia.Let(input, tensor.New(tensor.WithShape(batchSize, channels, size, size), tensor.WithBacking(append([]float32{}, inputData...))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = vm.RunAll()
+			vm.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := append([]float32{}, convOut.Value().(*tensor.Dense).Float32s()...)
+			spatial := len(expected) / (batchSize * filters)
+			for i := range expected {
+				expected[i] += biases[(i/spatial)%filters]
+				if activation == "leaky" && expected[i] < 0 {
+					expected[i] *= 0.1
+				}
+			}
+
+			layer := &convLayer{
+				filters:            filters,
+				kernelSize:         kernelSize,
+				padding:            1,
+				stride:             stride,
+				activation:         activation,
+				activationReLUCoef: 0.1,
+				biases:             biases,
+				convNode:           kernelNode,
+				quantization: &convQuantization{
+					inputScale:   maxAbsFloat32(inputData) / int8Range,
+					weightScales: perChannelScales(kernels, filters),
+				},
+			}
+			op, err := newQuantizedConvOp(layer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := op.Do(tensor.New(tensor.WithShape(batchSize, channels, size, size), tensor.WithBacking(append([]float32{}, inputData...))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !assert.Equal(t, convOut.Shape(), out.Shape(), "Wrong output shape for stride %d", stride) {
+				continue
+			}
+			// Every product has error of rounding of both input and weight: at most half of scale of each of them
+			fanIn := float32(channels * kernelSize * kernelSize)
+			tolerance := fanIn * (maxAbsFloat32(inputData)*maxAbsFloat32(kernels)/int8Range + maxAbsFloat32(inputData)*maxAbsFloat32(kernels)/(4*int8Range*int8Range))
+			assert.InDeltaSlice(t, expected, out.Data().([]float32), float64(tolerance), "Int8 convolution differs from float32 one (stride %d, activation %s)", stride, activation)
+		}
+	}
+}
+
+func TestPerChannelScales(t *testing.T) {
+	kernels := []float32{
+		0.5, -1.27, 0.1, 0,
+		0, 0, 0, 0,
+		2.54, 1, -1, 0.3,
+	}
+	assert.Equal(t, []float32{0.01, 1, 0.02}, perChannelScales(kernels, 3), "Scale is maximum absolute weight of channel divided by 127 (1 for empty channel)")
+	assert.Equal(t, int8(127), quantizeF32(2.54, 0.01), "Values out of range should be saturated")
+	assert.Equal(t, int8(-127), quantizeF32(-3, 0.01), "Values out of range should be saturated")
+	assert.Equal(t, int8(-50), quantizeF32(-0.5, 0.01))
+}
+
+func TestCalibrator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yolo_calibration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	weightsFile := filepath.Join(dir, "micro.weights")
+	writeTestWeights(t, "./test_network_data/yolov3-micro.cfg", weightsFile, 1)
+	calibrator := NewCalibrator()
+	model := newMicroModel(t, weightsFile, WithCalibrator(calibrator))
+
+	// Network which has not been run can't be quantized
+	_, err = calibrator.Quantization(model)
+	assert.Error(t, err)
+
+	// Range of the first convolution layer is range of input images
+	rng := rand.New(rand.NewSource(1))
+	vm := gorgonia.NewTapeMachine(model.g)
+	defer vm.Close()
+	maxAbs := float32(0.0)
+	for i := 0; i < 2; i++ {
+		img := randomFloat32s(rng, 3*32*32)
+		for j := range img {
+			img[j] *= float32(i + 1)
+		}
+		if m := maxAbsFloat32(img); m > maxAbs {
+			maxAbs = m
+		}
+		err = gorgonia.Let(model.input, tensor.New(tensor.WithShape(1, 3, 32, 32), tensor.WithBacking(img)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = vm.RunAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		vm.Reset()
+	}
+	quantization, err := calibrator.Quantization(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	convs := model.convLayers()
+	if !assert.Len(t, quantization.layers, len(convs)) {
+		return
+	}
+	first := quantization.layers[convs[0].layerIndex]
+	assert.InDelta(t, maxAbs/int8Range, first.inputScale, 1e-7)
+	for _, conv := range convs {
+		assert.Equal(t, 2, calibrator.passes[conv.layerIndex], "Every forward pass should be observed for layer #%d", conv.layerIndex)
+		q := quantization.layers[conv.layerIndex]
+		assert.InDelta(t, calibrator.maxAbs[conv.layerIndex]/int8Range, q.inputScale, 1e-7)
+		assert.Equal(t, perChannelScales(conv.convNode.Value().(*tensor.Dense).Float32s(), conv.filters), q.weightScales)
+	}
+}
@@ -0,0 +1,229 @@
+package yologo
+
+import (
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"runtime"
+	"sync"
+
+	"github.com/chewxy/hm"
+	"github.com/pkg/errors"
+	"gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// quantizedConvOp Fused int8 convolution, bias addition and activation
+/*
+	Inputs are quantized with per-layer scale, kernels - with per-channel scales.
+	Accumulation is done in int32, then result is dequantized to float32, biases are added and activation is applied.
+*/
+type quantizedConvOp struct {
+	layerIndex   int
+	filters      int
+	kernelSize   int
+	padding      int
+	stride       int
+	activation   string
+	leakyCoef    float32
+	inputScale   float32
+	weightScales []float32
+	weights      []int8
+	biases       []float32
+}
+
+func newQuantizedConvOp(l *convLayer) (*quantizedConvOp, error) {
+	kernels, ok := l.convNode.Value().Data().([]float32)
+	if !ok {
+		return nil, fmt.Errorf("Kernels of convolution layer #%d should be type of []float32", l.layerIndex)
+	}
+	if len(l.quantization.weightScales) != l.filters {
+		return nil, fmt.Errorf("Convolution layer #%d has %d filters, but quantization contains %d scales", l.layerIndex, l.filters, len(l.quantization.weightScales))
+	}
+	op := &quantizedConvOp{
+		layerIndex:   l.layerIndex,
+		filters:      l.filters,
+		kernelSize:   l.kernelSize,
+		padding:      l.padding,
+		stride:       l.stride,
+		activation:   l.activation,
+		leakyCoef:    float32(l.activationReLUCoef),
+		inputScale:   l.quantization.inputScale,
+		weightScales: l.quantization.weightScales,
+		weights:      make([]int8, len(kernels)),
+		biases:       make([]float32, len(l.biases)),
+	}
+	copy(op.biases, l.biases)
+	channelSize := len(kernels) / l.filters
+	for i := range kernels {
+		op.weights[i] = quantizeF32(kernels[i], op.weightScales[i/channelSize])
+	}
+	return op, nil
+}
+
+/* Methods to match gorgonia.Op interface */
+
+func (op *quantizedConvOp) Arity() int        { return 1 }
+func (op *quantizedConvOp) ReturnsPtr() bool  { return false }
+func (op *quantizedConvOp) CallsExtern() bool { return false }
+func (op *quantizedConvOp) WriteHash(h hash.Hash) {
+	fmt.Fprintf(h, "QuantizedConv{}(layer: (%d), filters: (%d), kernel: (%d), stride: (%d))", op.layerIndex, op.filters, op.kernelSize, op.stride)
+}
+func (op *quantizedConvOp) Hashcode() uint32 {
+	h := fnv.New32a()
+	op.WriteHash(h)
+	return h.Sum32()
+}
+func (op *quantizedConvOp) String() string {
+	return fmt.Sprintf("QuantizedConv{}(layer: (%d), filters: (%d), kernel: (%d), stride: (%d))", op.layerIndex, op.filters, op.kernelSize, op.stride)
+}
+func (op *quantizedConvOp) InferShape(inputs ...gorgonia.DimSizer) (tensor.Shape, error) {
+	shp := inputs[0].(tensor.Shape)
+	if len(shp) != 4 {
+		return nil, fmt.Errorf("InferShape() for quantized convolution must contain 4 dimensions, but recieved %d)", len(shp))
+	}
+	h, w := op.outputSize(shp[2], shp[3])
+	return tensor.Shape{shp[0], op.filters, h, w}, nil
+}
+func (op *quantizedConvOp) Type() hm.Type {
+	a := hm.TypeVariable('a')
+	t := gorgonia.TensorType{Dims: 4, Of: a}
+	return hm.NewFnType(t, t)
+}
+func (op *quantizedConvOp) OverwritesInput() int { return -1 }
+
+func (op *quantizedConvOp) Do(inputs ...gorgonia.Value) (gorgonia.Value, error) {
+	if err := checkArity(op, len(inputs)); err != nil {
+		return nil, errors.Wrap(err, "Can't check arity for quantized convolution")
+	}
+	in, ok := inputs[0].(tensor.Tensor)
+	if !ok {
+		return nil, errors.Errorf("Expected input to be a tensor")
+	}
+	inData, ok := in.Data().([]float32)
+	if !ok {
+		return nil, fmt.Errorf("Only Float32 supported for inputs of quantized convolution, but got %v", in.Dtype())
+	}
+	shp := in.Shape()
+	batchSize, channels, h, w := shp[0], shp[1], shp[2], shp[3]
+	if channels*op.kernelSize*op.kernelSize != len(op.weights)/op.filters {
+		return nil, fmt.Errorf("Quantized convolution #%d expects %d input channels, but got %d", op.layerIndex, len(op.weights)/op.filters/(op.kernelSize*op.kernelSize), channels)
+	}
+	outH, outW := op.outputSize(h, w)
+	out := tensor.New(tensor.Of(tensor.Float32), tensor.WithShape(batchSize, op.filters, outH, outW), tensor.WithEngine(in.Engine()))
+	outData := out.Data().([]float32)
+
+	inSize := channels * h * w
+	outSize := op.filters * outH * outW
+	quantized := make([]int8, inSize)
+	rows := make([]int8, channels*op.kernelSize*op.kernelSize*outH*outW)
+	for b := 0; b < batchSize; b++ {
+		for i, v := range inData[b*inSize : (b+1)*inSize] {
+			quantized[i] = quantizeF32(v, op.inputScale)
+		}
+		op.im2row(quantized, rows, channels, h, w, outH, outW)
+		op.gemm(rows, outData[b*outSize:(b+1)*outSize], outH*outW)
+	}
+	return out, nil
+}
+
+/* Unexported methods */
+
+func (op *quantizedConvOp) outputSize(h, w int) (int, int) {
+	outH := (h+2*op.padding-op.kernelSize)/op.stride + 1
+	outW := (w+2*op.padding-op.kernelSize)/op.stride + 1
+	return outH, outW
+}
+
+// im2row Unfolds quantized input into matrix [outH*outW, channels*kernel*kernel]
+// (each row is receptive field of single output position, so kernels and rows are multiplied as contiguous vectors)
+func (op *quantizedConvOp) im2row(input []int8, rows []int8, channels, h, w, outH, outW int) {
+	k := op.kernelSize
+	rowSize := channels * k * k
+	for oy := 0; oy < outH; oy++ {
+		for ox := 0; ox < outW; ox++ {
+			row := rows[(oy*outW+ox)*rowSize : (oy*outW+ox+1)*rowSize]
+			idx := 0
+			for c := 0; c < channels; c++ {
+				for ky := 0; ky < k; ky++ {
+					y := oy*op.stride - op.padding + ky
+					for kx := 0; kx < k; kx++ {
+						x := ox*op.stride - op.padding + kx
+						if y < 0 || y >= h || x < 0 || x >= w {
+							row[idx] = 0
+						} else {
+							row[idx] = input[(c*h+y)*w+x]
+						}
+						idx++
+					}
+				}
+			}
+		}
+	}
+}
+
+// gemm Multiplies int8 kernels by unfolded input in parallel over output positions, dequantizes result and applies bias and activation
+func (op *quantizedConvOp) gemm(rows []int8, out []float32, spatial int) {
+	// Every worker processes chunk of positions; chunks are multiple of 4 since 4 positions are computed at once
+	workers := runtime.GOMAXPROCS(0)
+	chunk := (spatial/workers + 4) / 4 * 4
+	var wg sync.WaitGroup
+	for start := 0; start < spatial; start += chunk {
+		end := MinInt(start+chunk, spatial)
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			op.gemmChunk(rows, out, spatial, start, end)
+		}(start, end)
+	}
+	wg.Wait()
+}
+
+func (op *quantizedConvOp) gemmChunk(rows []int8, out []float32, spatial, start, end int) {
+	rowSize := len(op.weights) / op.filters
+	s := start
+	for ; s+4 <= end; s += 4 {
+		r0 := rows[s*rowSize : (s+1)*rowSize]
+		r1 := rows[(s+1)*rowSize : (s+2)*rowSize]
+		r2 := rows[(s+2)*rowSize : (s+3)*rowSize]
+		r3 := rows[(s+3)*rowSize : (s+4)*rowSize]
+		for f := 0; f < op.filters; f++ {
+			weights := op.weights[f*rowSize : (f+1)*rowSize]
+			a0, a1, a2, a3 := dot4Int8(weights, r0, r1, r2, r3)
+			op.store(out, f*spatial+s, f, a0)
+			op.store(out, f*spatial+s+1, f, a1)
+			op.store(out, f*spatial+s+2, f, a2)
+			op.store(out, f*spatial+s+3, f, a3)
+		}
+	}
+	for ; s < end; s++ {
+		r := rows[s*rowSize : (s+1)*rowSize]
+		for f := 0; f < op.filters; f++ {
+			a, _, _, _ := dot4Int8(op.weights[f*rowSize:(f+1)*rowSize], r, r, r, r)
+			op.store(out, f*spatial+s, f, a)
+		}
+	}
+}
+
+// store Dequantizes accumulated value, adds bias and applies activation
+func (op *quantizedConvOp) store(out []float32, idx, filter int, acc int32) {
+	v := float32(acc)*op.inputScale*op.weightScales[filter] + op.biases[filter]
+	if op.activation == "leaky" && v < 0 {
+		v *= op.leakyCoef
+	}
+	out[idx] = v
+}
+
+// dot4Int8 Dot products of single vector with four other vectors
+func dot4Int8(w, r0, r1, r2, r3 []int8) (int32, int32, int32, int32) {
+	r0, r1, r2, r3 = r0[:len(w)], r1[:len(w)], r2[:len(w)], r3[:len(w)]
+	var a0, a1, a2, a3 int32
+	for i, x := range w {
+		xv := int32(x)
+		a0 += xv * int32(r0[i])
+		a1 += xv * int32(r1[i])
+		a2 += xv * int32(r2[i])
+		a3 += xv * int32(r3[i])
+	}
+	return a0, a1, a2, a3
+}
@@ -0,0 +1,219 @@
+package yologo
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"sort"
+
+	"github.com/chewxy/math32"
+)
+
+// roiOverlapSamples Maximal number of sample points along each side of box for estimation of overlap with region of interest
+const roiOverlapSamples = 32
+
+// Polygon Closed polygon (last point is connected to the first one)
+type Polygon []image.Point
+
+// Contains Checks if point (x; y) is inside of polygon (even-odd rule)
+func (polygon Polygon) Contains(x, y float32) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		xi, yi := float32(polygon[i].X), float32(polygon[i].Y)
+		xj, yj := float32(polygon[j].X), float32(polygon[j].Y)
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// ROIFilter Method of filtering detections by regions of interest
+type ROIFilter int
+
+const (
+	// ROIByCenter Detection is kept if center of its box is inside of allowed region
+	ROIByCenter = ROIFilter(iota)
+	// ROIByOverlap Detection is kept if fraction of its box inside of allowed region is not less than ROIMask.MinOverlap
+	ROIByOverlap
+)
+
+// ROIMask Regions of interest in coordinates of original image
+/*
+	Allowed region is union of Include polygons (whole image if there are none) minus union of Exclude polygons.
+*/
+type ROIMask struct {
+	Include []Polygon
+	Exclude []Polygon
+	Filter  ROIFilter
+	// MinOverlap Minimal fraction of box inside of allowed region for ROIByOverlap filter
+	MinOverlap float32
+	// BlankPixels Fill pixels outside of allowed region with gray color before inference
+	BlankPixels bool
+}
+
+// allowed Checks if point (x; y) is inside of allowed region
+func (mask *ROIMask) allowed(x, y float32) bool {
+	for _, polygon := range mask.Exclude {
+		if polygon.Contains(x, y) {
+			return false
+		}
+	}
+	if len(mask.Include) == 0 {
+		return true
+	}
+	for _, polygon := range mask.Include {
+		if polygon.Contains(x, y) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlap Estimates fraction of rectangle inside of allowed region on uniform grid of sample points
+func (mask *ROIMask) overlap(rect image.Rectangle) float32 {
+	if rect.Empty() {
+		return 0
+	}
+	nx, ny := MinInt(rect.Dx(), roiOverlapSamples), MinInt(rect.Dy(), roiOverlapSamples)
+	stepX, stepY := float32(rect.Dx())/float32(nx), float32(rect.Dy())/float32(ny)
+	inside := 0
+	for j := 0; j < ny; j++ {
+		y := float32(rect.Min.Y) + (float32(j)+0.5)*stepY
+		for i := 0; i < nx; i++ {
+			if mask.allowed(float32(rect.Min.X)+(float32(i)+0.5)*stepX, y) {
+				inside++
+			}
+		}
+	}
+	return float32(inside) / float32(nx*ny)
+}
+
+// roiOptions Mask of detector and cached raster of allowed region for the last size of image
+type roiOptions struct {
+	mask         ROIMask
+	raster       []bool
+	rasterBounds image.Rectangle
+}
+
+// WithROIMask Filters detections by regions of interest (e.g. sky or neighbouring property of fixed camera are excluded)
+/*
+	Filtering is applied to final detections of any mode (plain, test-time augmentation or tiling).
+	With mask.BlankPixels pixels outside of allowed region are filled with gray color before inference;
+	raster of allowed region is cached for the last size of image.
+*/
+func WithROIMask(mask ROIMask) DetectorOption {
+	return func(opts *detectorOptions) {
+		opts.roi = &roiOptions{
+			mask: mask,
+		}
+	}
+}
+
+// validate Checks polygons and parameters of mask
+func (mask *ROIMask) validate() error {
+	for i, polygon := range mask.Include {
+		if len(polygon) < 3 {
+			return fmt.Errorf("Include polygon #%d should contain at least 3 points, but got %d", i, len(polygon))
+		}
+	}
+	for i, polygon := range mask.Exclude {
+		if len(polygon) < 3 {
+			return fmt.Errorf("Exclude polygon #%d should contain at least 3 points, but got %d", i, len(polygon))
+		}
+	}
+	switch mask.Filter {
+	case ROIByCenter:
+	case ROIByOverlap:
+		if mask.MinOverlap < 0 || mask.MinOverlap > 1 {
+			return fmt.Errorf("Minimal overlap with region of interest should be in range [0; 1], but got %f", mask.MinOverlap)
+		}
+	default:
+		return fmt.Errorf("Unknown ROI filter %d", mask.Filter)
+	}
+	return nil
+}
+
+// filter Returns detections which pass mask
+func (roi *roiOptions) filter(dets Detections) Detections {
+	filtered := make(Detections, 0, len(dets))
+	for _, det := range dets {
+		switch roi.mask.Filter {
+		case ROIByOverlap:
+			if roi.mask.overlap(det.rect) < roi.mask.MinOverlap {
+				continue
+			}
+		default:
+			if !roi.mask.allowed(float32(det.rect.Min.X+det.rect.Max.X)/2, float32(det.rect.Min.Y+det.rect.Max.Y)/2) {
+				continue
+			}
+		}
+		filtered = append(filtered, det)
+	}
+	return filtered
+}
+
+// blank Returns copy of image where pixels outside of allowed region are filled with gray color
+func (roi *roiOptions) blank(img image.Image) image.Image {
+	bounds := img.Bounds()
+	if roi.raster == nil || roi.rasterBounds != bounds {
+		roi.raster = roi.mask.rasterize(bounds)
+		roi.rasterBounds = bounds
+	}
+	blanked := image.NewRGBA(bounds)
+	draw.Draw(blanked, bounds, img, bounds.Min, draw.Src)
+	gray := color.RGBA{R: 127, G: 127, B: 127, A: 255}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row := (y - bounds.Min.Y) * bounds.Dx()
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if !roi.raster[row+x-bounds.Min.X] {
+				blanked.SetRGBA(x, y, gray)
+			}
+		}
+	}
+	return blanked
+}
+
+// rasterize Returns allowed region for every pixel of bounds (row by row), pixel is tested by its center
+func (mask *ROIMask) rasterize(bounds image.Rectangle) []bool {
+	include := make([]bool, bounds.Dx()*bounds.Dy())
+	if len(mask.Include) == 0 {
+		for i := range include {
+			include[i] = true
+		}
+	}
+	for _, polygon := range mask.Include {
+		fillPolygon(include, bounds, polygon, true)
+	}
+	for _, polygon := range mask.Exclude {
+		fillPolygon(include, bounds, polygon, false)
+	}
+	return include
+}
+
+// fillPolygon Sets value for pixels whose centers are inside of polygon (scanline with even-odd rule)
+func fillPolygon(raster []bool, bounds image.Rectangle, polygon Polygon, value bool) {
+	crossings := make([]float32, 0, len(polygon))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		cy := float32(y) + 0.5
+		crossings = crossings[:0]
+		for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+			xi, yi := float32(polygon[i].X), float32(polygon[i].Y)
+			xj, yj := float32(polygon[j].X), float32(polygon[j].Y)
+			if (yi > cy) != (yj > cy) {
+				crossings = append(crossings, (xj-xi)*(cy-yi)/(yj-yi)+xi)
+			}
+		}
+		sort.Slice(crossings, func(a, b int) bool { return crossings[a] < crossings[b] })
+		row := (y - bounds.Min.Y) * bounds.Dx()
+		for k := 0; k+1 < len(crossings); k += 2 {
+			// Pixel x is inside if crossings[k] <= x + 0.5 < crossings[k+1]
+			from := MaxInt(int(math32.Ceil(crossings[k]-0.5)), bounds.Min.X)
+			to := MinInt(int(math32.Ceil(crossings[k+1]-0.5)), bounds.Max.X)
+			for x := from; x < to; x++ {
+				raster[row+x-bounds.Min.X] = value
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package yologo
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestROIMask(t *testing.T) {
+	mask := ROIMask{
+		// Lower half of image without small square in its center
+		Include: []Polygon{{{0, 50}, {100, 50}, {100, 100}, {0, 100}}},
+		Exclude: []Polygon{{{40, 60}, {60, 60}, {60, 80}, {40, 80}}},
+	}
+	if err := mask.validate(); err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, mask.allowed(50, 20), "Point above include polygon")
+	assert.True(t, mask.allowed(20, 70), "Point inside of include polygon")
+	assert.False(t, mask.allowed(50, 70), "Point inside of exclude polygon")
+
+	// Raster should agree with point test for every pixel center
+	bounds := image.Rect(0, 0, 100, 100)
+	raster := mask.rasterize(bounds)
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			if raster[y*100+x] != mask.allowed(float32(x)+0.5, float32(y)+0.5) {
+				t.Fatalf("Raster differs from point test for pixel (%d; %d)", x, y)
+			}
+		}
+	}
+
+	inside := &DetectionRectangle{rect: image.Rect(5, 60, 25, 90)}
+	excluded := &DetectionRectangle{rect: image.Rect(45, 65, 55, 75)}
+	// Center is excluded, but ~57% of box is allowed
+	partial := &DetectionRectangle{rect: image.Rect(30, 55, 58, 85)}
+	dets := Detections{inside, excluded, partial}
+
+	roi := &roiOptions{mask: mask}
+	assert.Equal(t, Detections{inside}, roi.filter(dets), "Wrong filtering by center")
+	roi.mask.Filter = ROIByOverlap
+	roi.mask.MinOverlap = 0.5
+	assert.Equal(t, Detections{inside, partial}, roi.filter(dets), "Wrong filtering by overlap")
+
+	mask.Include = []Polygon{{{0, 0}, {10, 10}}}
+	assert.Error(t, mask.validate(), "Degenerate polygon should be rejected")
+}
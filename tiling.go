@@ -0,0 +1,152 @@
+package yologo
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sort"
+)
+
+// tileBorderMargin Box which is closer than this number of pixels to inner border of tile is considered as cut by that border
+const tileBorderMargin = 2
+
+type tilingOptions struct {
+	overlap float32
+	full    bool
+}
+
+// WithTiling Detects objects on tiles of network's size which are cut from image in its original resolution
+/*
+	Useful for very large images with tiny objects (e.g. aerial ones) which disappear when whole image is resized to network's size.
+	Neighbouring tiles overlap by fraction 'overlap' of tile's size (should be in range [0; 1)). Tiles are processed in batches
+	of network's batch size. If 'full' is set, whole resized image is processed too, so large objects are found as well.
+	Boxes are shifted to coordinates of image; boxes cut by borders of tiles are merged with overlapping boxes of the same class
+	from other tiles (if intersection over area of smaller box exceeds IoU threshold of detector) and then NMS is applied.
+	Tiling can't be combined with test-time augmentation.
+*/
+func WithTiling(overlap float32, full bool) DetectorOption {
+	return func(opts *detectorOptions) {
+		opts.tiling = &tilingOptions{
+			overlap: overlap,
+			full:    full,
+		}
+	}
+}
+
+// tiledDetection Detection of single tile in coordinates of image
+type tiledDetection struct {
+	det  *DetectionRectangle
+	tile int
+	// cut Box touches border of tile which is not border of image
+	cut bool
+}
+
+// tileOffsets Returns positions of tiles along axis of given length
+func tileOffsets(length, tile int, overlap float32) []int {
+	if length <= tile {
+		return []int{0}
+	}
+	step := MaxInt(int(float32(tile)*(1-overlap)), 1)
+	offsets := []int{}
+	for offset := 0; offset+tile < length; offset += step {
+		offsets = append(offsets, offset)
+	}
+	// The last tile is aligned to the end of axis
+	return append(offsets, length-tile)
+}
+
+// detectTiled Detects objects on every tile of image and merges detections
+func (d *Detector) detectTiled(img image.Image) (Detections, error) {
+	shp := d.net.input.Shape()
+	batchSize, netHeight, netWidth := shp[0], shp[2], shp[3]
+	bounds := img.Bounds()
+	tiles := []image.Rectangle{}
+	for _, y := range tileOffsets(bounds.Dy(), netHeight, d.tiling.overlap) {
+		for _, x := range tileOffsets(bounds.Dx(), netWidth, d.tiling.overlap) {
+			tiles = append(tiles, image.Rect(x, y, x+netWidth, y+netHeight).Add(bounds.Min))
+		}
+	}
+
+	found := []tiledDetection{}
+	for start := 0; start < len(tiles); start += batchSize {
+		end := MinInt(start+batchSize, len(tiles))
+		inputs := make([]image.Image, 0, end-start)
+		for _, tile := range tiles[start:end] {
+			// Tiles which stick out of image (small images only) are padded with gray color
+			input := image.NewRGBA(image.Rect(0, 0, netWidth, netHeight))
+			draw.Draw(input, input.Bounds(), &image.Uniform{C: color.Gray{Y: 127}}, image.Point{}, draw.Src)
+			draw.Draw(input, input.Bounds(), img, tile.Min, draw.Src)
+			inputs = append(inputs, input)
+		}
+		batch, err := d.forwardBatch(inputs, WithDetectionsOrder(nil))
+		if err != nil {
+			return nil, err
+		}
+		for i, dets := range batch {
+			tile := tiles[start+i]
+			for _, det := range dets {
+				det.rect = det.rect.Add(tile.Min).Intersect(bounds)
+				if det.rect.Empty() {
+					continue
+				}
+				found = append(found, tiledDetection{
+					det:  det,
+					tile: start + i,
+					cut: (det.rect.Min.X-tile.Min.X <= tileBorderMargin && tile.Min.X > bounds.Min.X) ||
+						(det.rect.Min.Y-tile.Min.Y <= tileBorderMargin && tile.Min.Y > bounds.Min.Y) ||
+						(tile.Max.X-det.rect.Max.X <= tileBorderMargin && tile.Max.X < bounds.Max.X) ||
+						(tile.Max.Y-det.rect.Max.Y <= tileBorderMargin && tile.Max.Y < bounds.Max.Y),
+				})
+			}
+		}
+	}
+	if d.tiling.full {
+		dets, err := d.forward(scaleImage(img, netWidth, netHeight), WithDetectionsOrder(nil))
+		if err != nil {
+			return nil, err
+		}
+		scaleDetections(dets, float32(bounds.Dx())/float32(netWidth), float32(bounds.Dy())/float32(netHeight), bounds.Min)
+		for _, det := range dets {
+			found = append(found, tiledDetection{det: det, tile: -1})
+		}
+	}
+
+	dets := NonMaxSuppression(mergeCutDetections(found, d.iouThreshold), d.iouThreshold, WithGridNMS())
+	dets.SortBy(ByX)
+	return dets, nil
+}
+
+// mergeCutDetections Merges boxes cut by borders of tiles with overlapping boxes of the same class from other tiles
+/*
+	Boxes are processed in order of rank: box absorbs (its rectangle becomes union of both) every box of other tile
+	if at least one of them is cut and intersection over area of smaller box exceeds threshold.
+*/
+func mergeCutDetections(found []tiledDetection, threshold float32) Detections {
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].det.conf*found[i].det.score > found[j].det.conf*found[j].det.score
+	})
+	merged := make(Detections, 0, len(found))
+	absorbed := make([]bool, len(found))
+	for i := range found {
+		if absorbed[i] {
+			continue
+		}
+		current := found[i]
+		for j := i + 1; j < len(found); j++ {
+			other := found[j]
+			if absorbed[j] || other.det.classIdx != current.det.classIdx || other.tile == current.tile || (!current.cut && !other.cut) {
+				continue
+			}
+			inter := current.det.rect.Intersect(other.det.rect)
+			smaller := MinInt(current.det.rect.Dx()*current.det.rect.Dy(), other.det.rect.Dx()*other.det.rect.Dy())
+			if smaller <= 0 || float32(inter.Dx()*inter.Dy())/float32(smaller) <= threshold {
+				continue
+			}
+			current.det.rect = current.det.rect.Union(other.det.rect)
+			current.cut = current.cut && other.cut
+			absorbed[j] = true
+		}
+		merged = append(merged, current.det)
+	}
+	return merged
+}
@@ -0,0 +1,120 @@
+package yologo
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTileOffsets(t *testing.T) {
+	tests := []struct {
+		name    string
+		length  int
+		tile    int
+		overlap float32
+		offsets []int
+	}{
+		{"tile larger than image", 300, 416, 0.2, []int{0}},
+		{"tile equal to image", 416, 416, 0.2, []int{0}},
+		{"last tile flush with edge", 100, 50, 0, []int{0, 50}},
+		{"last tile shifted back to edge", 101, 50, 0, []int{0, 50, 51}},
+		{"half overlap", 100, 40, 0.5, []int{0, 20, 40, 60}},
+		{"overlap rounds step down", 100, 30, 0.25, []int{0, 22, 44, 66, 70}},
+		{"overlap equal to tile", 5, 3, 1, []int{0, 1, 2}},
+		{"overlap greater than tile", 5, 3, 1.5, []int{0, 1, 2}},
+	}
+	for _, test := range tests {
+		offsets := tileOffsets(test.length, test.tile, test.overlap)
+		assert.Equal(t, test.offsets, offsets, "Wrong offsets for case '%s'", test.name)
+		// Tiles cover whole axis
+		last := offsets[len(offsets)-1]
+		assert.True(t, last+test.tile >= test.length, "Last tile doesn't reach end of axis for case '%s'", test.name)
+	}
+}
+
+func TestMergeCutDetections(t *testing.T) {
+	detection := func(score float32, classIdx int, rect image.Rectangle) *DetectionRectangle {
+		return &DetectionRectangle{conf: 1, score: score, classIdx: classIdx, rect: rect}
+	}
+	tests := []struct {
+		name  string
+		found []tiledDetection
+		rects []image.Rectangle
+	}{
+		{
+			name: "cut halves of object from neighbouring tiles",
+			found: []tiledDetection{
+				{det: detection(0.6, 0, image.Rect(90, 10, 100, 30)), tile: 1, cut: true},
+				{det: detection(0.9, 0, image.Rect(80, 10, 100, 30)), tile: 0, cut: true},
+			},
+			rects: []image.Rectangle{image.Rect(80, 10, 100, 30)},
+		},
+		{
+			name: "cut box is absorbed by whole one",
+			found: []tiledDetection{
+				{det: detection(0.9, 0, image.Rect(80, 10, 120, 30)), tile: 0},
+				{det: detection(0.5, 0, image.Rect(100, 10, 120, 30)), tile: 1, cut: true},
+			},
+			rects: []image.Rectangle{image.Rect(80, 10, 120, 30)},
+		},
+		{
+			name: "boxes of the same tile",
+			found: []tiledDetection{
+				{det: detection(0.9, 0, image.Rect(80, 10, 100, 30)), tile: 0, cut: true},
+				{det: detection(0.6, 0, image.Rect(90, 10, 100, 30)), tile: 0, cut: true},
+			},
+			rects: []image.Rectangle{image.Rect(80, 10, 100, 30), image.Rect(90, 10, 100, 30)},
+		},
+		{
+			name: "boxes which are not cut",
+			found: []tiledDetection{
+				{det: detection(0.9, 0, image.Rect(80, 10, 100, 30)), tile: 0},
+				{det: detection(0.6, 0, image.Rect(90, 10, 100, 30)), tile: 1},
+			},
+			rects: []image.Rectangle{image.Rect(80, 10, 100, 30), image.Rect(90, 10, 100, 30)},
+		},
+		{
+			name: "different classes",
+			found: []tiledDetection{
+				{det: detection(0.9, 0, image.Rect(80, 10, 100, 30)), tile: 0, cut: true},
+				{det: detection(0.6, 1, image.Rect(90, 10, 100, 30)), tile: 1, cut: true},
+			},
+			rects: []image.Rectangle{image.Rect(80, 10, 100, 30), image.Rect(90, 10, 100, 30)},
+		},
+		{
+			name: "overlap equal to threshold",
+			found: []tiledDetection{
+				{det: detection(0.9, 0, image.Rect(80, 10, 100, 30)), tile: 0, cut: true},
+				{det: detection(0.6, 0, image.Rect(90, 10, 110, 30)), tile: 1, cut: true},
+			},
+			rects: []image.Rectangle{image.Rect(80, 10, 100, 30), image.Rect(90, 10, 110, 30)},
+		},
+		{
+			name: "small overlap",
+			found: []tiledDetection{
+				{det: detection(0.9, 0, image.Rect(80, 10, 100, 30)), tile: 0, cut: true},
+				{det: detection(0.6, 0, image.Rect(98, 10, 120, 30)), tile: 1, cut: true},
+			},
+			rects: []image.Rectangle{image.Rect(80, 10, 100, 30), image.Rect(98, 10, 120, 30)},
+		},
+		{
+			name: "object cut by four tiles",
+			found: []tiledDetection{
+				{det: detection(0.8, 0, image.Rect(80, 80, 100, 100)), tile: 0, cut: true},
+				{det: detection(0.7, 0, image.Rect(85, 80, 105, 100)), tile: 1, cut: true},
+				{det: detection(0.6, 0, image.Rect(80, 85, 100, 105)), tile: 2, cut: true},
+				{det: detection(0.5, 0, image.Rect(85, 85, 105, 105)), tile: 3, cut: true},
+			},
+			rects: []image.Rectangle{image.Rect(80, 80, 105, 105)},
+		},
+	}
+	for _, test := range tests {
+		merged := mergeCutDetections(test.found, 0.5)
+		rects := make([]image.Rectangle, len(merged))
+		for i := range merged {
+			rects[i] = merged[i].rect
+		}
+		assert.Equal(t, test.rects, rects, "Wrong merged boxes for case '%s'", test.name)
+	}
+}
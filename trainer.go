@@ -0,0 +1,441 @@
+package yologo
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// TrainerOption Option for NewTrainer constructor
+type TrainerOption func(*trainerOptions)
+
+type trainerOptions struct {
+	learningRate float64
+	optimizer    Optimizer
+	ema          *emaOptions
+	seed         int64
+	hasSeed      bool
+	shuffle      bool
+	augmentation *Augmentation
+	loader       loaderOptions
+	callbacks    *TrainerCallbacks
+	validation   *periodicOptions
+	checkpoints  *periodicOptions
+}
+
+// WithLearningRate Sets learning rate of default optimizer (default is 0.00001)
+func WithLearningRate(learningRate float64) TrainerOption {
+	return func(opts *trainerOptions) {
+		opts.learningRate = learningRate
+	}
+}
+
+// WithOptimizer Sets optimizer (see NewSGD(), NewAdam(), NewRMSProp()); default one is RMSProp with decay 0.999
+/*
+	Learning rate of given optimizer is used as is (WithLearningRate() affects default optimizer only).
+*/
+func WithOptimizer(optimizer Optimizer) TrainerOption {
+	return func(opts *trainerOptions) {
+		opts.optimizer = optimizer
+	}
+}
+
+// WithSeed Sets seed of every random source of trainer (shuffling and augmentation), so runs with the same seed are identical
+/*
+	Default seed is taken from current time. Randomly initialized network (see WithRandomInit()) is trained with seed
+	of its initialization by default and another seed is rejected, so single seed reproduces training from scratch.
+*/
+func WithSeed(seed int64) TrainerOption {
+	return func(opts *trainerOptions) {
+		opts.seed = seed
+		opts.hasSeed = true
+	}
+}
+
+// WithShuffle Shuffles order of images on each epoch of TrainFolder()
+func WithShuffle() TrainerOption {
+	return func(opts *trainerOptions) {
+		opts.shuffle = true
+	}
+}
+
+// WithAugmentation Applies random transformations to images of TrainFolder() before every step
+func WithAugmentation(augmentation Augmentation) TrainerOption {
+	return func(opts *trainerOptions) {
+		opts.augmentation = &augmentation
+	}
+}
+
+// Trainer Trains kernels of convolution layers (LearningNodes) of YOLOv3 network
+/*
+	Trainer activates training mode of network, so cost function is sum of outputs of all YOLO layers.
+	Network should be created for single image (batch size of input equals to 1).
+	Trainer holds its own tape machines (of training step and of forward pass only for validation) and is not safe for concurrent use.
+*/
+type Trainer struct {
+	net       *YOLOv3
+	tm        gorgonia.VM
+	forwardTM gorgonia.VM
+	optimizer Optimizer
+	costs     *gorgonia.Node
+	ema       *weightsEMA
+	rng       *rand.Rand
+	opts      trainerOptions
+}
+
+// NewTrainer Prepares gradients and solver for given network
+func NewTrainer(net *YOLOv3, options ...TrainerOption) (*Trainer, error) {
+	opts := trainerOptions{
+		learningRate: 0.00001,
+		loader: loaderOptions{
+			workers:  2,
+			prefetch: 4,
+		},
+	}
+	for _, o := range options {
+		o(&opts)
+	}
+	if net.randomInit != nil {
+		if opts.hasSeed && opts.seed != net.randomInit.seed {
+			return nil, fmt.Errorf("Seed of trainer should be equal to seed of network's random initialization (%d), but got %d", net.randomInit.seed, opts.seed)
+		}
+		opts.seed, opts.hasSeed = net.randomInit.seed, true
+	}
+	if !opts.hasSeed {
+		opts.seed = time.Now().UnixNano()
+	}
+	if opts.optimizer == nil {
+		opts.optimizer = NewRMSProp(opts.learningRate, 0.999)
+	}
+	if opts.callbacks == nil {
+		opts.callbacks = &TrainerCallbacks{OnIteration: printIteration}
+	}
+	if net.input == nil || net.g == nil {
+		return nil, fmt.Errorf("Network doesn't contain graph or input node")
+	}
+	if net.input.Shape()[0] != 1 {
+		return nil, fmt.Errorf("Training supports networks with batch size 1 only, but got %d", net.input.Shape()[0])
+	}
+	err := net.ActivateTrainingMode()
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't activate training mode")
+	}
+	concatOut, err := gorgonia.Concat(1, net.GetOutput()...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't concatenate YOLO layers outputs")
+	}
+	costs, err := gorgonia.Sum(concatOut, 0, 1, 2)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't evaluate costs")
+	}
+	_, err = gorgonia.Grad(costs, net.LearningNodes...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't evaluate gradients")
+	}
+	prog, locMap, err := gorgonia.Compile(net.g)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't compile graph")
+	}
+	// Subgraph of cost function doesn't contain gradients, so validation skips backward pass
+	forwardProg, forwardLocMap, err := gorgonia.Compile(net.g.ExactSubgraphRoots(costs))
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't compile forward pass")
+	}
+	var ema *weightsEMA
+	if opts.ema != nil {
+		ema, err = newWeightsEMA(net.LearningNodes, *opts.ema)
+		if err != nil {
+			return nil, errors.Wrap(err, "Can't prepare EMA of weights")
+		}
+	}
+	return &Trainer{
+		net:       net,
+		tm:        gorgonia.NewTapeMachine(net.g, gorgonia.WithPrecompiled(prog, locMap), gorgonia.BindDualValues(net.LearningNodes...)),
+		forwardTM: gorgonia.NewTapeMachine(net.g, gorgonia.WithPrecompiled(forwardProg, forwardLocMap)),
+		optimizer: opts.optimizer,
+		costs:     costs,
+		ema:       ema,
+		rng:       rand.New(rand.NewSource(opts.seed)),
+		opts:      opts,
+	}, nil
+}
+
+// Optimizer Returns optimizer of trainer (e.g. for change of learning rate between steps)
+func (t *Trainer) Optimizer() Optimizer {
+	return t.optimizer
+}
+
+// Close Closes underlying tape machines
+func (t *Trainer) Close() error {
+	if err := t.forwardTM.Close(); err != nil {
+		return err
+	}
+	return t.tm.Close()
+}
+
+// Step Does single training step: forward and backward passes on image and update of LearningNodes
+/*
+	imgf32 - image of network's input size (see Image2Float32()), target - darknet annotations of image (see ParseLabels()),
+	empty target is background-only image which contributes no-object loss only.
+	Returns value of cost function.
+*/
+func (t *Trainer) Step(imgf32, target []float32) (float32, error) {
+	err := t.net.SetTarget(target)
+	if err != nil {
+		return 0, errors.Wrap(err, "Can't set []float32 as target")
+	}
+	imgTensor := tensor.New(tensor.WithShape(t.net.input.Shape()...), tensor.Of(tensor.Float32), tensor.WithBacking(imgf32))
+	err = gorgonia.Let(t.net.input, imgTensor)
+	if err != nil {
+		return 0, errors.Wrap(err, "Can't let input = []float32")
+	}
+	// Do not forget to reset tape machine on each step
+	defer t.tm.Reset()
+	if err := t.tm.RunAll(); err != nil {
+		return 0, errors.Wrap(err, "Can't run tape machine")
+	}
+	cost, ok := t.costs.Value().Data().(float32)
+	if !ok {
+		return 0, fmt.Errorf("Cost should be type of float32")
+	}
+	err = t.optimizer.Step(gorgonia.NodesToValueGrads(t.net.LearningNodes))
+	if err != nil {
+		return 0, errors.Wrap(err, "Can't do optimizer step")
+	}
+	if t.ema != nil {
+		err = t.ema.update(t.net.LearningNodes)
+		if err != nil {
+			return 0, errors.Wrap(err, "Can't update EMA of weights")
+		}
+	}
+	return cost, nil
+}
+
+// RunWithEMA Calls f while network holds averaged weights (see WithEMA()), raw weights are restored afterwards
+/*
+	If EMA is disabled, f is called on raw weights. Network must not be trained inside of f.
+*/
+func (t *Trainer) RunWithEMA(f func() error) error {
+	if t.ema == nil {
+		return f()
+	}
+	err := t.ema.swap(t.net.LearningNodes)
+	if err != nil {
+		return errors.Wrap(err, "Can't apply EMA of weights")
+	}
+	fErr := f()
+	err = t.ema.swap(t.net.LearningNodes)
+	if err != nil {
+		return errors.Wrap(err, "Can't restore raw weights")
+	}
+	return fErr
+}
+
+// SaveWeights Writes weights of network (averaged ones if EMA is enabled) in darknet format (see YOLOv3.SaveWeights())
+func (t *Trainer) SaveWeights(fname string, half bool) error {
+	return t.RunWithEMA(func() error {
+		return t.net.SaveWeights(fname, half)
+	})
+}
+
+// TrainFolder Trains network on labeled folder (see ParseLabeledFolder()) for given number of epochs
+/*
+	Images are processed in order of their names unless WithShuffle() is used.
+	They are prepared in background (see WithDataLoader()), so training steps don't wait for decoding of images.
+	Progress is reported to hooks (see WithCallbacks()), optional validation and checkpoints are done after epochs
+	(see WithValidation() and WithCheckpoints()).
+*/
+func (t *Trainer) TrainFolder(dir string, epochs int) error {
+	err := t.trainFolder(dir, epochs)
+	if errors.Cause(err) == ErrStopTraining {
+		return nil
+	}
+	return err
+}
+
+// trainFolder Does training loop of TrainFolder()
+func (t *Trainer) trainFolder(dir string, epochs int) error {
+	labeledData, err := ParseLabeledFolder(dir)
+	if err != nil {
+		return errors.Wrap(err, "Can't prepare labeled data")
+	}
+	names := make([]string, 0, len(labeledData))
+	for name := range labeledData {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	done := make(chan struct{})
+	queue, finished := t.loadSamples(dir, names, labeledData, epochs, done)
+	defer func() {
+		// Loader must stop before next use of trainer (it shares random source of trainer)
+		close(done)
+		<-finished
+	}()
+
+	start := time.Now()
+	epochStart := start
+	epoch := EpochInfo{}
+	iter := 0
+	st := time.Now()
+	for slot := range queue {
+		sample := <-slot
+		waitTime := time.Since(st)
+		if sample.err != nil {
+			return sample.err
+		}
+		if sample.epoch != epoch.Epoch {
+			err = t.finishEpoch(epoch, iter, epochStart, start)
+			if err != nil {
+				return err
+			}
+			epoch = EpochInfo{Epoch: sample.epoch}
+			epochStart = time.Now()
+		}
+		st = time.Now()
+		learningRate := t.optimizer.LearningRate()
+		cost, err := t.Step(sample.img, sample.target)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Can't do training step on image '%s'", sample.name))
+		}
+		info := IterationInfo{
+			Iteration:    iter,
+			Epoch:        sample.epoch,
+			Image:        sample.name,
+			Cost:         cost,
+			Losses:       t.losses(),
+			LearningRate: learningRate,
+			StepTime:     time.Since(st),
+			WaitTime:     waitTime,
+			Elapsed:      time.Since(start),
+		}
+		epoch.Iterations++
+		epoch.Losses.add(info.Losses)
+		iter++
+		if t.opts.callbacks.OnIteration != nil {
+			err = t.opts.callbacks.OnIteration(info)
+			if err != nil {
+				return err
+			}
+		}
+		st = time.Now()
+	}
+	if epoch.Iterations > 0 {
+		return t.finishEpoch(epoch, iter, epochStart, start)
+	}
+	return nil
+}
+
+// finishEpoch Reports summary of epoch, then does validation and saves checkpoint if they are due
+func (t *Trainer) finishEpoch(epoch EpochInfo, iter int, epochStart, start time.Time) error {
+	callbacks := t.opts.callbacks
+	epoch.Losses = epoch.Losses.scale(1 / float32(MaxInt(epoch.Iterations, 1)))
+	epoch.LearningRate = t.optimizer.LearningRate()
+	epoch.Duration = time.Since(epochStart)
+	epoch.Elapsed = time.Since(start)
+	if callbacks.OnEpoch != nil {
+		err := callbacks.OnEpoch(epoch)
+		if err != nil {
+			return err
+		}
+	}
+	if t.opts.validation.due(epoch.Epoch) {
+		var info ValidationInfo
+		err := t.RunWithEMA(func() (err error) {
+			info, err = t.validate(t.opts.validation.path)
+			return err
+		})
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Can't validate epoch #%d", epoch.Epoch))
+		}
+		info.Epoch = epoch.Epoch
+		if callbacks.OnValidation != nil {
+			err = callbacks.OnValidation(info)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if t.opts.checkpoints.due(epoch.Epoch) {
+		fname := filepath.Join(t.opts.checkpoints.path, fmt.Sprintf("epoch_%d.weights", epoch.Epoch+1))
+		err := t.SaveWeights(fname, t.opts.checkpoints.half)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Can't save checkpoint of epoch #%d", epoch.Epoch))
+		}
+		if callbacks.OnCheckpoint != nil {
+			err = callbacks.OnCheckpoint(CheckpointInfo{Epoch: epoch.Epoch, Iteration: iter, Path: fname})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validate Evaluates mean losses of current weights on labeled folder without update of weights
+func (t *Trainer) validate(dir string) (ValidationInfo, error) {
+	st := time.Now()
+	info := ValidationInfo{}
+	labeledData, err := ParseLabeledFolder(dir)
+	if err != nil {
+		return info, errors.Wrap(err, "Can't prepare labeled data")
+	}
+	names := make([]string, 0, len(labeledData))
+	for name := range labeledData {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	shp := t.net.input.Shape()
+	for _, name := range names {
+		imgf32, err := GetFloat32Image(filepath.Join(dir, name+".jpg"), shp[3], shp[2])
+		if err != nil {
+			return info, errors.Wrap(err, fmt.Sprintf("Can't read []float32 from image '%s'", name))
+		}
+		losses, err := t.forward(imgf32, labeledData[name])
+		if err != nil {
+			return info, errors.Wrap(err, fmt.Sprintf("Can't evaluate losses on image '%s'", name))
+		}
+		info.Losses.add(losses)
+		info.Images++
+	}
+	info.Losses = info.Losses.scale(1 / float32(MaxInt(info.Images, 1)))
+	info.Duration = time.Since(st)
+	return info, nil
+}
+
+// forward Evaluates losses on image without update of weights (backward pass is not done)
+func (t *Trainer) forward(imgf32, target []float32) (Losses, error) {
+	err := t.net.SetTarget(target)
+	if err != nil {
+		return Losses{}, errors.Wrap(err, "Can't set []float32 as target")
+	}
+	imgTensor := tensor.New(tensor.WithShape(t.net.input.Shape()...), tensor.Of(tensor.Float32), tensor.WithBacking(imgf32))
+	err = gorgonia.Let(t.net.input, imgTensor)
+	if err != nil {
+		return Losses{}, errors.Wrap(err, "Can't let input = []float32")
+	}
+	defer t.forwardTM.Reset()
+	if err := t.forwardTM.RunAll(); err != nil {
+		return Losses{}, errors.Wrap(err, "Can't run tape machine")
+	}
+	return t.losses(), nil
+}
+
+// losses Returns components of cost function summed over YOLO layers (evaluated by the last forward pass)
+/*
+	YOLO layers which don't implement LossReporter are skipped.
+*/
+func (t *Trainer) losses() Losses {
+	losses := Losses{}
+	for i := range t.net.training {
+		if reporter, ok := t.net.training[i].(LossReporter); ok {
+			losses.add(reporter.Losses())
+		}
+	}
+	return losses
+}
@@ -0,0 +1,273 @@
+package yologo
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorgonia.org/tensor"
+)
+
+// prepareMicroTraining Writes synthetic weights of micro network and few labeled images into temporary folder
+func prepareMicroTraining(t *testing.T, dir string) string {
+	weightsFile := filepath.Join(dir, "micro.weights")
+	err := WriteSyntheticWeights("./test_network_data/yolov3-micro.cfg", weightsFile, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataDir := filepath.Join(dir, "data")
+	err = os.Mkdir(dataDir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 3; i++ {
+		img := image.NewRGBA(image.Rect(0, 0, 32, 32))
+		for p := 0; p < len(img.Pix); p += 4 {
+			img.Pix[p], img.Pix[p+1], img.Pix[p+2], img.Pix[p+3] = uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 255
+		}
+		// Bright square is the object
+		for y := 8; y < 20; y++ {
+			for x := 4 + 2*i; x < 16+2*i; x++ {
+				img.Set(x, y, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+			}
+		}
+		file, err := os.Create(filepath.Join(dataDir, fmt.Sprintf("%d.jpg", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = jpeg.Encode(file, img, nil)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		label := fmt.Sprintf("%d %f 0.4375 0.375 0.375\n", i%2, float32(10+2*i)/32)
+		err = ioutil.WriteFile(filepath.Join(dataDir, fmt.Sprintf("%d.txt", i)), []byte(label), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return weightsFile
+}
+
+// microTrainerFactory Creates trainer of new micro network (see microTraining())
+type microTrainerFactory func(modelOptions []ModelOption, options ...TrainerOption) *Trainer
+
+// microTraining Prepares training of micro network in temporary folder (see prepareMicroTraining())
+/*
+	Returns folder of labeled images and factory of trainers. Every trainer gets its own network built from synthetic weights
+	(unless model options say otherwise, e.g. WithRandomInit()). Networks and trainers are closed and temporary folder is removed
+	at the end of test.
+*/
+func microTraining(t *testing.T) (string, microTrainerFactory) {
+	dir, err := ioutil.TempDir("", "yolo_trainer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	weightsFile := prepareMicroTraining(t, dir)
+	newTrainer := func(modelOptions []ModelOption, options ...TrainerOption) *Trainer {
+		model := newMicroModel(t, weightsFile, modelOptions...)
+		t.Cleanup(func() {
+			model.Close()
+		})
+		trainer, err := NewTrainer(model, options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			trainer.Close()
+		})
+		return trainer
+	}
+	return filepath.Join(dir, "data"), newTrainer
+}
+
+// trainMicro Trains micro network for few epochs and returns costs of every step
+func trainMicro(t *testing.T, dataDir string, newTrainer microTrainerFactory, modelOptions []ModelOption, options ...TrainerOption) []float32 {
+	costs := []float32{}
+	callbacks := TrainerCallbacks{
+		OnIteration: func(info IterationInfo) error {
+			costs = append(costs, info.Cost)
+			return nil
+		},
+	}
+	trainer := newTrainer(modelOptions, append(options, WithCallbacks(callbacks))...)
+	err := trainer.TrainFolder(dataDir, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return costs
+}
+
+func TestTrainerSeed(t *testing.T) {
+	dataDir, newTrainer := microTraining(t)
+
+	options := func(seed int64) []TrainerOption {
+		return []TrainerOption{
+			WithSeed(seed),
+			WithShuffle(),
+			WithAugmentation(Augmentation{Flip: 0.5, Exposure: 0.3, Saturation: 0.3}),
+			WithOptimizer(NewAdam(0.001, 0.9, 0.999)),
+		}
+	}
+	first := trainMicro(t, dataDir, newTrainer, nil, options(42)...)
+	second := trainMicro(t, dataDir, newTrainer, nil, options(42)...)
+	if !assert.Len(t, first, 9) {
+		return
+	}
+	assert.Equal(t, first, second)
+	other := trainMicro(t, dataDir, newTrainer, nil, options(7)...)
+	assert.NotEqual(t, first, other)
+
+	// Number of loader's workers and size of prefetch don't affect order and augmentation of samples
+	sequential := trainMicro(t, dataDir, newTrainer, nil, append(options(42), WithDataLoader(1, 0))...)
+	assert.Equal(t, first, sequential)
+	parallel := trainMicro(t, dataDir, newTrainer, nil, append(options(42), WithDataLoader(4, 8))...)
+	assert.Equal(t, first, parallel)
+
+}
+
+func TestTrainerRandomInitSeed(t *testing.T) {
+	dataDir, newTrainer := microTraining(t)
+
+	// trainFromScratch Trains randomly initialized micro network and returns its kernels
+	trainFromScratch := func(initSeed int64, options ...TrainerOption) [][]float32 {
+		options = append(options,
+			WithShuffle(),
+			WithAugmentation(Augmentation{Flip: 0.5, Exposure: 0.3, Saturation: 0.3}),
+			WithOptimizer(NewAdam(0.001, 0.9, 0.999)),
+			WithCallbacks(TrainerCallbacks{}),
+		)
+		trainer := newTrainer([]ModelOption{WithRandomInit(InitHeNormal, initSeed)}, options...)
+		err := trainer.TrainFolder(dataDir, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kernels := [][]float32{}
+		for _, conv := range trainer.net.convLayers() {
+			kernels = append(kernels, append([]float32{}, conv.convNode.Value().(*tensor.Dense).Float32s()...))
+		}
+		return kernels
+	}
+
+	// Seed of initialization drives shuffling and augmentation too
+	first := trainFromScratch(42)
+	assert.Equal(t, first, trainFromScratch(42), "Runs with the same seed should produce identical weights")
+	assert.Equal(t, first, trainFromScratch(42, WithSeed(42)))
+	assert.NotEqual(t, first, trainFromScratch(7))
+
+	model := newMicroModel(t, "", WithRandomInit(InitHeNormal, 42))
+	defer model.Close()
+	_, err := NewTrainer(model, WithSeed(7))
+	assert.Error(t, err, "Seed of trainer should match seed of random initialization")
+}
+
+func TestTrainerForward(t *testing.T) {
+	dataDir, newTrainer := microTraining(t)
+	trainer := newTrainer(nil, WithOptimizer(NewAdam(0.001, 0.9, 0.999)))
+	labeledData, err := ParseLabeledFolder(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imgf32, err := GetFloat32Image(filepath.Join(dataDir, "0.jpg"), 32, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weights := make([][]float32, len(trainer.net.LearningNodes))
+	for i, n := range trainer.net.LearningNodes {
+		weights[i] = append([]float32{}, n.Value().(*tensor.Dense).Float32s()...)
+	}
+
+	losses, err := trainer.forward(imgf32, labeledData["0"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := trainer.forward(imgf32, labeledData["0"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, losses, again)
+	assert.True(t, losses.Total() > 0)
+
+	// Forward pass neither updates weights nor evaluates gradients
+	for i, n := range trainer.net.LearningNodes {
+		assert.Equal(t, weights[i], n.Value().(*tensor.Dense).Float32s(), "Weights of node '%s' should not change", n.Name())
+		grad, err := n.Grad()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dense, ok := grad.(*tensor.Dense); ok {
+			for _, g := range dense.Float32s() {
+				if g != 0 {
+					t.Fatalf("Gradient of node '%s' should not be evaluated", n.Name())
+				}
+			}
+		}
+	}
+
+	// Training step evaluates the same cost before update of weights
+	cost, err := trainer.Step(imgf32, labeledData["0"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.InDelta(t, losses.Total(), cost, 1e-3*float64(cost))
+}
+
+func TestTrainerLoaderError(t *testing.T) {
+	dataDir, newTrainer := microTraining(t)
+	err := os.Remove(filepath.Join(dataDir, "1.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := 0
+	callbacks := TrainerCallbacks{
+		OnIteration: func(info IterationInfo) error {
+			steps++
+			return nil
+		},
+	}
+	trainer := newTrainer(nil, WithDataLoader(3, 2), WithCallbacks(callbacks), WithShuffle(), WithAugmentation(Augmentation{Flip: 0.5}))
+	err = trainer.TrainFolder(dataDir, 2)
+	assert.Error(t, err)
+	// Missing image is met during the first epoch
+	assert.True(t, steps < 3, "Training should stop on missing image, but got %d steps", steps)
+
+	// Loader of failed run has stopped, so trainer can be used again (run with -race to check random source of trainer)
+	trainer.rng.Int63()
+	for i := 0; i < 3; i++ {
+		err = trainer.TrainFolder(dataDir, 2)
+		assert.Error(t, err)
+	}
+}
+
+func TestTrainerNegatives(t *testing.T) {
+	dataDir, newTrainer := microTraining(t)
+	// Image '1' becomes background-only sample
+	err := ioutil.WriteFile(filepath.Join(dataDir, "1.txt"), []byte{}, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	costs := trainMicro(t, dataDir, newTrainer, nil, WithOptimizer(NewAdam(0.001, 0.9, 0.999)))
+	if !assert.Len(t, costs, 9) {
+		return
+	}
+	for i, cost := range costs {
+		assert.False(t, math.IsNaN(float64(cost)) || math.IsInf(float64(cost), 0), "Cost of step #%d should be finite, but got %f", i, cost)
+		assert.True(t, cost > 0, "Cost of step #%d should be positive, but got %f", i, cost)
+	}
+
+	trainer := newTrainer(nil)
+	_, err = trainer.Step(make([]float32, 3*32*32), []float32{0, 0.5, 0.5})
+	assert.Error(t, err, "Target which is not made of 5-value annotations should be rejected")
+}
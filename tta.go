@@ -0,0 +1,123 @@
+package yologo
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// TTAMerge Method of merging detections of augmented inputs
+type TTAMerge int
+
+const (
+	// TTAMergeNMS Non-maximum suppression over detections of all inputs
+	TTAMergeNMS = TTAMerge(iota)
+	// TTAMergeWBF Weighted boxes fusion: coordinates of overlapping boxes are averaged and objects found on few inputs get lower confidence
+	TTAMergeWBF
+)
+
+type ttaOptions struct {
+	scales []float32
+	flip   bool
+	merge  TTAMerge
+}
+
+// WithTestTimeAugmentation Runs network on several augmented versions of every image and merges their detections
+/*
+	Besides original input network is run on horizontally flipped one (if flip is set) and on inputs rescaled by every factor of scales:
+	image is shrunk and padded with gray color, so scales should be in range (0; 1]. Flip is applied to rescaled inputs too.
+	Boxes are mapped back to original image and merged via NMS or weighted boxes fusion (see TTAMerge).
+	Every image costs (1 + len(scales)) forward passes (twice as many with flip).
+*/
+func WithTestTimeAugmentation(scales []float32, flip bool, merge TTAMerge) DetectorOption {
+	return func(opts *detectorOptions) {
+		opts.tta = &ttaOptions{
+			scales: scales,
+			flip:   flip,
+			merge:  merge,
+		}
+	}
+}
+
+// ttaPass Augmentation of single forward pass
+type ttaPass struct {
+	// width, height Size of rescaled image in top left corner of network's input
+	width, height int
+	// scaleX, scaleY Ratio of network's input size to size of rescaled image
+	scaleX, scaleY float32
+	flip           bool
+}
+
+// restore Maps rectangle found on augmented input back to network's coordinates of original input
+func (p ttaPass) restore(rect, netBounds image.Rectangle) image.Rectangle {
+	if p.flip {
+		rect = image.Rect(p.width-rect.Max.X, rect.Min.Y, p.width-rect.Min.X, rect.Max.Y)
+	}
+	return image.Rect(
+		int(float32(rect.Min.X)*p.scaleX),
+		int(float32(rect.Min.Y)*p.scaleY),
+		int(float32(rect.Max.X)*p.scaleX),
+		int(float32(rect.Max.Y)*p.scaleY),
+	).Intersect(netBounds)
+}
+
+// detectAugmented Detects objects on every augmented input and merges detections
+func (d *Detector) detectAugmented(img image.Image) (Detections, error) {
+	shp := d.net.input.Shape()
+	netHeight, netWidth := shp[2], shp[3]
+	netBounds := image.Rect(0, 0, netWidth, netHeight)
+	flips := []bool{false}
+	if d.tta.flip {
+		flips = append(flips, true)
+	}
+	passes := []Detections{}
+	for _, scale := range append([]float32{1}, d.tta.scales...) {
+		width := MaxInt(int(float32(netWidth)*scale+0.5), 1)
+		height := MaxInt(int(float32(netHeight)*scale+0.5), 1)
+		resized := scaleImage(img, width, height)
+		for _, flip := range flips {
+			pass := ttaPass{
+				width:  width,
+				height: height,
+				scaleX: float32(netWidth) / float32(width),
+				scaleY: float32(netHeight) / float32(height),
+				flip:   flip,
+			}
+			input := image.NewRGBA(netBounds)
+			draw.Draw(input, netBounds, &image.Uniform{C: color.Gray{Y: 127}}, image.Point{}, draw.Src)
+			if flip {
+				for y := 0; y < height; y++ {
+					for x := 0; x < width; x++ {
+						input.SetRGBA(width-1-x, y, resized.RGBAAt(x, y))
+					}
+				}
+			} else {
+				draw.Draw(input, resized.Bounds(), resized, image.Point{}, draw.Src)
+			}
+			dets, err := d.forward(input, WithDetectionsOrder(nil))
+			if err != nil {
+				return nil, err
+			}
+			for _, det := range dets {
+				det.rect = pass.restore(det.rect, netBounds)
+			}
+			passes = append(passes, dets)
+		}
+	}
+
+	var dets Detections
+	switch d.tta.merge {
+	case TTAMergeWBF:
+		dets = weightedBoxesFusion(passes, nil, d.iouThreshold)
+	default:
+		all := Detections{}
+		for _, pass := range passes {
+			all = append(all, pass...)
+		}
+		dets = NonMaxSuppression(all, d.iouThreshold, WithGridNMS())
+	}
+	dets.SortBy(ByX)
+	bounds := img.Bounds()
+	scaleDetections(dets, float32(bounds.Dx())/float32(netWidth), float32(bounds.Dy())/float32(netHeight), bounds.Min)
+	return dets, nil
+}
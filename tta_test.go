@@ -0,0 +1,66 @@
+package yologo
+
+import (
+	"image"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTTARestore(t *testing.T) {
+	netBounds := image.Rect(0, 0, 416, 416)
+	rect := image.Rect(10, 20, 50, 60)
+	tests := []struct {
+		name     string
+		pass     ttaPass
+		rect     image.Rectangle
+		expected image.Rectangle
+	}{
+		{"original", ttaPass{width: 416, height: 416, scaleX: 1, scaleY: 1}, rect, rect},
+		{"flipped", ttaPass{width: 416, height: 416, scaleX: 1, scaleY: 1, flip: true}, rect, image.Rect(366, 20, 406, 60)},
+		{"scaled", ttaPass{width: 208, height: 208, scaleX: 2, scaleY: 2}, rect, image.Rect(20, 40, 100, 120)},
+		{"scaled and flipped", ttaPass{width: 208, height: 208, scaleX: 2, scaleY: 2, flip: true}, rect, image.Rect(316, 40, 396, 120)},
+		// Box which covers padding of rescaled input is clipped by bounds of network's input
+		{"scaled over padding", ttaPass{width: 208, height: 208, scaleX: 2, scaleY: 2}, image.Rect(200, 0, 300, 100), image.Rect(400, 0, 416, 200)},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.pass.restore(test.rect, netBounds), "Wrong restored rectangle of %s pass", test.name)
+	}
+}
+
+func TestDetectAugmented(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yolo_tta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	weightsFile := filepath.Join(dir, "micro.weights")
+	writeTestWeights(t, "./test_network_data/yolov3-micro.cfg", weightsFile, 1)
+	model := newMicroModel(t, weightsFile)
+
+	_, err = NewDetector(model, []string{"first", "second"}, 0, 0.5, WithTestTimeAugmentation([]float32{1.5}, true, TTAMergeNMS))
+	assert.Error(t, err, "Scales greater than 1 should be rejected")
+
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	rng.Read(img.Pix)
+	for _, merge := range []TTAMerge{TTAMergeNMS, TTAMergeWBF} {
+		detector, err := NewDetector(model, []string{"first", "second"}, 0, 0.5, WithTestTimeAugmentation([]float32{0.5}, true, merge))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dets, err := detector.Detect(img)
+		detector.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.NotEmpty(t, dets, "No detections for merge method %d", merge)
+		for _, det := range dets {
+			assert.True(t, det.GetRectangle().In(img.Bounds()), "Detection %v should be inside image for merge method %d", det.GetRectangle(), merge)
+		}
+	}
+}
@@ -213,3 +213,46 @@ func getAverageColor(img image.Image, minX int, maxX int, minY int, maxY int) co
 	averageColor := color.RGBA{R: uint8(averageRed), G: uint8(averageGreen), B: uint8(averageBlue), A: uint8(averageAlpha)}
 	return averageColor
 }
+
+// scaleImage Bilinear image resizing to arbitrary size (both downscaling and upscaling)
+func scaleImage(img image.Image, width, height int) *image.RGBA {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	resImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	if srcW == 0 || srcH == 0 {
+		return resImg
+	}
+	scaleX := float64(srcW) / float64(width)
+	scaleY := float64(srcH) / float64(height)
+	for y := 0; y < height; y++ {
+		sy := (float64(y)+0.5)*scaleY - 0.5
+		y0 := int(math.Floor(sy))
+		dy := sy - float64(y0)
+		y1 := MinInt(y0+1, srcH-1)
+		y0 = MaxInt(MinInt(y0, srcH-1), 0)
+		for x := 0; x < width; x++ {
+			sx := (float64(x)+0.5)*scaleX - 0.5
+			x0 := int(math.Floor(sx))
+			dx := sx - float64(x0)
+			x1 := MinInt(x0+1, srcW-1)
+			x0 = MaxInt(MinInt(x0, srcW-1), 0)
+			r00, g00, b00, a00 := img.At(bounds.Min.X+x0, bounds.Min.Y+y0).RGBA()
+			r10, g10, b10, a10 := img.At(bounds.Min.X+x1, bounds.Min.Y+y0).RGBA()
+			r01, g01, b01, a01 := img.At(bounds.Min.X+x0, bounds.Min.Y+y1).RGBA()
+			r11, g11, b11, a11 := img.At(bounds.Min.X+x1, bounds.Min.Y+y1).RGBA()
+			resImg.SetRGBA(x, y, color.RGBA{
+				R: uint8(bilinear(r00, r10, r01, r11, dx, dy) / 257),
+				G: uint8(bilinear(g00, g10, g01, g11, dx, dy) / 257),
+				B: uint8(bilinear(b00, b10, b01, b11, dx, dy) / 257),
+				A: uint8(bilinear(a00, a10, a01, a11, dx, dy) / 257),
+			})
+		}
+	}
+	return resImg
+}
+
+func bilinear(v00, v10, v01, v11 uint32, dx, dy float64) float64 {
+	top := float64(v00)*(1-dx) + float64(v10)*dx
+	bottom := float64(v01)*(1-dx) + float64(v11)*dx
+	return top*(1-dy) + bottom*dy
+}
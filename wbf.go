@@ -0,0 +1,169 @@
+package yologo
+
+import (
+	"fmt"
+	"image"
+	"sort"
+)
+
+// FuseDetections Ensembles detections of several models via weighted boxes fusion
+/*
+	lists - detections of every model for the same image (in the same coordinates and with the same classes),
+	weights - weight of every model (nil means equal weights).
+	Detections with confidence multiplied by class probability below skipThreshold are ignored.
+	Overlapping boxes of the same class (IoU > iouThreshold) are fused into single one: its coordinates are average of boxes' ones
+	weighted by scores and weights of models. Confidence and class probability are weighted averages too,
+	and confidence is scaled down by fraction of total weight of models which found the object, so objects found by single model
+	of ensemble get lower scores than objects found by every model.
+	Returned detections are sorted by score in descending order.
+*/
+func FuseDetections(lists []Detections, weights []float32, iouThreshold, skipThreshold float32) (Detections, error) {
+	if weights != nil && len(weights) != len(lists) {
+		return nil, fmt.Errorf("Number of weights should be equal to number of lists of detections (%d), but got %d", len(lists), len(weights))
+	}
+	for i := range weights {
+		if weights[i] < 0 {
+			return nil, fmt.Errorf("Weight of model #%d should not be negative, but got %f", i, weights[i])
+		}
+	}
+	sources := make([]Detections, len(lists))
+	for i := range lists {
+		sources[i] = make(Detections, 0, len(lists[i]))
+		for _, d := range lists[i] {
+			if d.conf*d.score >= skipThreshold {
+				sources[i] = append(sources[i], d)
+			}
+		}
+	}
+	return weightedBoxesFusion(sources, weights, iouThreshold), nil
+}
+
+// wbfBox Detection of single source which takes part in fusion
+type wbfBox struct {
+	nmsBox
+	det *DetectionRectangle
+	// weight Weight of source which produced detection
+	weight float32
+}
+
+// wbfCluster Group of boxes which are fused into single one
+type wbfCluster struct {
+	fused nmsBox
+	boxes []wbfBox
+}
+
+// update Recalculates coordinates of fused box: they are average of boxes' ones weighted by rank and weight of source
+func (cluster *wbfCluster) update() {
+	sum := float32(0.0)
+	x1, y1, x2, y2 := float32(0.0), float32(0.0), float32(0.0), float32(0.0)
+	for _, box := range cluster.boxes {
+		w := box.rank * box.weight
+		x1 += w * box.x1
+		y1 += w * box.y1
+		x2 += w * box.x2
+		y2 += w * box.y2
+		sum += w
+	}
+	if sum <= 0 {
+		// Every box has zero rank: there is nothing to weight, so the first one is used
+		cluster.fused.x1, cluster.fused.y1, cluster.fused.x2, cluster.fused.y2 = cluster.boxes[0].x1, cluster.boxes[0].y1, cluster.boxes[0].x2, cluster.boxes[0].y2
+	} else {
+		cluster.fused.x1, cluster.fused.y1, cluster.fused.x2, cluster.fused.y2 = x1/sum, y1/sum, x2/sum, y2/sum
+	}
+	cluster.fused.area = (cluster.fused.x2 - cluster.fused.x1) * (cluster.fused.y2 - cluster.fused.y1)
+}
+
+// weightedBoxesFusion Fuses detections of several sources (models or augmented inputs) into single list
+/*
+	weights - weight of every source (nil means equal weights).
+	Boxes of the same class are clustered greedily in order of rank multiplied by weight of source:
+	box joins fused box with the highest IoU above iouThreshold or starts new cluster.
+	Coordinates of fused box are average of cluster's boxes weighted by rank and weight of source.
+	Confidence and class probability are averages weighted by weight of source; confidence is also multiplied by
+	min(W_cluster, W_total) / W_total (W_cluster - sum of weights of cluster's boxes, W_total - sum of weights of all sources),
+	so objects found by few sources get lower scores.
+	Returned detections are sorted by rank in descending order.
+	See ref. https://arxiv.org/abs/1910.13302
+*/
+func weightedBoxesFusion(sources []Detections, weights []float32, iouThreshold float32) Detections {
+	totalWeight := float32(0.0)
+	buckets := map[int][]wbfBox{}
+	for s, dets := range sources {
+		weight := float32(1.0)
+		if weights != nil {
+			weight = weights[s]
+		}
+		totalWeight += weight
+		for _, d := range dets {
+			box := wbfBox{
+				nmsBox: newNMSBox(float32(d.rect.Min.X), float32(d.rect.Min.Y), float32(d.rect.Max.X), float32(d.rect.Max.Y), d.conf*d.score, d.classIdx, len(buckets[d.classIdx])),
+				det:    d,
+				weight: weight,
+			}
+			buckets[d.classIdx] = append(buckets[d.classIdx], box)
+		}
+	}
+	if totalWeight <= 0 {
+		return Detections{}
+	}
+
+	// Classes are processed in fixed order, so result doesn't depend on iteration over map
+	classes := make([]int, 0, len(buckets))
+	for classIdx := range buckets {
+		classes = append(classes, classIdx)
+	}
+	sort.Ints(classes)
+	fused := Detections{}
+	for _, classIdx := range classes {
+		boxes := buckets[classIdx]
+		sort.Slice(boxes, func(i, j int) bool {
+			ri, rj := boxes[i].rank*boxes[i].weight, boxes[j].rank*boxes[j].weight
+			if ri == rj {
+				return boxes[i].idx < boxes[j].idx
+			}
+			return ri > rj
+		})
+		clusters := []*wbfCluster{}
+		for _, box := range boxes {
+			var best *wbfCluster
+			bestIOU := iouThreshold
+			for _, cluster := range clusters {
+				if iou := box.iou(&cluster.fused); iou > bestIOU {
+					best, bestIOU = cluster, iou
+				}
+			}
+			if best == nil {
+				best = &wbfCluster{}
+				clusters = append(clusters, best)
+			}
+			best.boxes = append(best.boxes, box)
+			best.update()
+		}
+		for _, cluster := range clusters {
+			conf, score, weight := float32(0.0), float32(0.0), float32(0.0)
+			for _, box := range cluster.boxes {
+				conf += box.det.conf * box.weight
+				score += box.det.score * box.weight
+				weight += box.weight
+			}
+			if weight <= 0 {
+				continue
+			}
+			first := cluster.boxes[0].det
+			fused = append(fused, &DetectionRectangle{
+				conf: conf / weight * minF32(weight, totalWeight) / totalWeight,
+				rect: image.Rect(
+					int(cluster.fused.x1+0.5),
+					int(cluster.fused.y1+0.5),
+					int(cluster.fused.x2+0.5),
+					int(cluster.fused.y2+0.5),
+				),
+				class:    first.class,
+				classIdx: first.classIdx,
+				score:    score / weight,
+			})
+		}
+	}
+	fused.SortBy(ByScore)
+	return fused
+}
@@ -0,0 +1,42 @@
+package yologo
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuseDetections(t *testing.T) {
+	modelA := Detections{
+		&DetectionRectangle{conf: 1.0, score: 0.8, rect: image.Rect(10, 10, 110, 110), class: "dog", classIdx: 0},
+		&DetectionRectangle{conf: 0.9, score: 0.9, rect: image.Rect(300, 300, 350, 350), class: "cat", classIdx: 1},
+	}
+	modelB := Detections{
+		&DetectionRectangle{conf: 0.5, score: 0.8, rect: image.Rect(20, 10, 120, 110), class: "dog", classIdx: 0},
+		// Too weak, should be skipped
+		&DetectionRectangle{conf: 0.1, score: 0.1, rect: image.Rect(200, 200, 220, 220), class: "dog", classIdx: 0},
+	}
+
+	fused, err := FuseDetections([]Detections{modelA, modelB}, []float32{3, 1}, 0.55, 0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, fused, 2) {
+		return
+	}
+	// Box found by both models: coordinates are weighted by score and weight of model, confidence is weighted average
+	dog := fused[0]
+	assert.Equal(t, "dog", dog.GetClass())
+	assert.Equal(t, image.Rect(11, 10, 111, 110), dog.GetRectangle())
+	assert.InDelta(t, (1.0*3+0.5*1)/4.0, dog.GetConfidence(), 1e-6)
+	assert.InDelta(t, 0.8, dog.GetScore(), 1e-6)
+	// Box found by first model only: confidence is scaled by weight of that model
+	cat := fused[1]
+	assert.Equal(t, "cat", cat.GetClass())
+	assert.Equal(t, image.Rect(300, 300, 350, 350), cat.GetRectangle())
+	assert.InDelta(t, 0.9*3.0/4.0, cat.GetConfidence(), 1e-6)
+
+	_, err = FuseDetections([]Detections{modelA, modelB}, []float32{1}, 0.55, 0)
+	assert.Error(t, err, "Number of weights should be checked")
+}
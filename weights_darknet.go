@@ -2,12 +2,23 @@ package yologo
 
 import (
 	"bufio"
+	"encoding/binary"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/chewxy/math32"
+	"github.com/pkg/errors"
 )
 
+// weightsHeaderSize Number of 4-byte values in header of darknet weights file (major, minor, revision and 64-bit 'seen' counter)
+const weightsHeaderSize = 5
+
 // ParseConfiguration Parse darknet configuration file
 func ParseConfiguration(fname string) ([]map[string]string, error) {
 	file, err := os.Open(fname)
@@ -52,6 +63,44 @@ func ParseConfiguration(fname string) ([]map[string]string, error) {
 	return blocks, nil
 }
 
+// InputChannels Returns number of channels of network's input from parameters of network ('channels' field, 3 by default)
+func InputChannels(netParams map[string]string) (int, error) {
+	channelsStr, ok := netParams["channels"]
+	if !ok {
+		return 3, nil
+	}
+	channels, err := strconv.Atoi(channelsStr)
+	if err != nil || channels < 1 {
+		return 0, fmt.Errorf("Network's channels must be positive integer, got value: '%s'", channelsStr)
+	}
+	return channels, nil
+}
+
+// WriteConfiguration Writes darknet configuration (see ParseConfiguration()) to file
+/*
+	Parameters of every section are written in alphabetical order.
+*/
+func WriteConfiguration(fname string, blocks []map[string]string) error {
+	var sb strings.Builder
+	for i, block := range blocks {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "[%s]\n", block["type"])
+		keys := make([]string, 0, len(block))
+		for key := range block {
+			if key != "type" {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&sb, "%s=%s\n", key, block[key])
+		}
+	}
+	return ioutil.WriteFile(fname, []byte(sb.String()), 0644)
+}
+
 // ParseWeights Parse darknet weights
 func ParseWeights(fname string) ([]float32, error) {
 	fp, err := os.Open(fname)
@@ -81,3 +130,122 @@ func ParseWeights(fname string) ([]float32, error) {
 	}
 	return dataF32, nil
 }
+
+// ParseWeightsF16 Parse darknet weights stored as IEEE 754 half precision numbers
+/*
+	Header (first 20 bytes) is kept as is, every other value is converted to float32.
+	Result has the same layout as result of ParseWeights().
+*/
+func ParseWeightsF16(fname string) ([]float32, error) {
+	summary, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	headerBytes := weightsHeaderSize * 4
+	if len(summary) < headerBytes || (len(summary)-headerBytes)%2 != 0 {
+		return nil, fmt.Errorf("Wrong size of half precision weights file: %d bytes", len(summary))
+	}
+	dataF32 := make([]float32, 0, weightsHeaderSize+(len(summary)-headerBytes)/2)
+	for i := 0; i < headerBytes; i += 4 {
+		dataF32 = append(dataF32, Float32frombytes(summary[i:i+4]))
+	}
+	for i := headerBytes; i < len(summary); i += 2 {
+		dataF32 = append(dataF32, Float16ToFloat32(binary.LittleEndian.Uint16(summary[i:i+2])))
+	}
+	return dataF32, nil
+}
+
+// WriteWeights Writes darknet weights (in layout of ParseWeights() result) to file
+/*
+	If half is true then every value except header is stored as IEEE 754 half precision number (see ParseWeightsF16()).
+*/
+func WriteWeights(fname string, data []float32, half bool) error {
+	if len(data) < weightsHeaderSize {
+		return fmt.Errorf("Weights should contain header of %d values, but got %d values only", weightsHeaderSize, len(data))
+	}
+	valueSize := 4
+	if half {
+		valueSize = 2
+	}
+	buf := make([]byte, weightsHeaderSize*4+(len(data)-weightsHeaderSize)*valueSize)
+	for i := 0; i < weightsHeaderSize; i++ {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(data[i]))
+	}
+	offset := weightsHeaderSize * 4
+	for _, v := range data[weightsHeaderSize:] {
+		if half {
+			binary.LittleEndian.PutUint16(buf[offset:], Float32ToFloat16(v))
+		} else {
+			binary.LittleEndian.PutUint32(buf[offset:], math.Float32bits(v))
+		}
+		offset += valueSize
+	}
+	return ioutil.WriteFile(fname, buf, 0644)
+}
+
+// SaveWeights Writes parameters of network's convolution layers to darknet weights file (see WriteWeights())
+/*
+	Batch normalization is already folded into kernels and biases, so it is written as identity transformation
+	(gamma = sqrt(1 + epsilon), mean = 0, variance = 1). Such weights are loaded without any modification (see WithMappedWeights()).
+*/
+func (net *YOLOv3) SaveWeights(fname string, half bool) error {
+	data := append([]float32{}, net.weightsHeader...)
+	identityGamma := math32.Sqrt(1 + batchNormEpsilon)
+	for _, conv := range net.convLayers() {
+		kernels, ok := conv.convNode.Value().Data().([]float32)
+		if !ok {
+			return fmt.Errorf("Kernels of convolution layer #%d should be type of []float32", conv.layerIndex)
+		}
+		if conv.batchNormalize <= 0 && !conv.bias {
+			data = append(data, kernels...)
+			continue
+		}
+		data = append(data, conv.biases...)
+		if conv.batchNormalize > 0 {
+			for range conv.biases {
+				data = append(data, identityGamma)
+			}
+			data = append(data, make([]float32, len(conv.biases))...)
+			for range conv.biases {
+				data = append(data, 1)
+			}
+		}
+		data = append(data, kernels...)
+	}
+	return WriteWeights(fname, data, half)
+}
+
+// ConvertWeights Converts darknet weights file between float32 and float16 storage
+/*
+	If toHalf is true then float32 file is converted to float16 one, otherwise float16 file is converted to float32 one.
+*/
+func ConvertWeights(srcFile, dstFile string, toHalf bool) error {
+	var data []float32
+	var err error
+	if toHalf {
+		data, err = ParseWeights(srcFile)
+	} else {
+		data, err = ParseWeightsF16(srcFile)
+	}
+	if err != nil {
+		return err
+	}
+	return WriteWeights(dstFile, data, toHalf)
+}
+
+// WriteSyntheticWeights Writes darknet weights file which matches given configuration and is filled with random values
+/*
+	Kernels are drawn from normal distribution with standard deviation sqrt(2 / fan_in), biases are zero and
+	batch normalization is identity transformation. Useful for benchmarks and tests when real weights are not available.
+*/
+func WriteSyntheticWeights(cfgFile, weightsFile string, seed int64) error {
+	blocks, err := ParseConfiguration(cfgFile)
+	if err != nil {
+		return errors.Wrap(err, "Can't read darknet configuration")
+	}
+	data, err := randomWeights(blocks, InitHeNormal, seed)
+	if err != nil {
+		return err
+	}
+	return WriteWeights(weightsFile, data, false)
+}
@@ -0,0 +1,108 @@
+package yologo
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/chewxy/math32"
+	"github.com/pkg/errors"
+)
+
+// WeightsInit Method of random initialization of convolution kernels
+type WeightsInit int
+
+const (
+	// InitHeNormal Kernels are drawn from normal distribution with standard deviation sqrt(2 / fan_in)
+	InitHeNormal = WeightsInit(iota)
+	// InitXavier Kernels are drawn from uniform distribution in range ±sqrt(6 / (fan_in + fan_out))
+	InitXavier
+	// InitDarknetUniform Kernels are drawn from uniform distribution in range ±sqrt(2 / fan_in) (as Darknet does)
+	InitDarknetUniform
+)
+
+// ParseWeightsInit Returns initialization method by its name: "he", "xavier" or "darknet"
+func ParseWeightsInit(name string) (WeightsInit, error) {
+	switch strings.ToLower(name) {
+	case "he":
+		return InitHeNormal, nil
+	case "xavier":
+		return InitXavier, nil
+	case "darknet":
+		return InitDarknetUniform, nil
+	default:
+		return 0, fmt.Errorf("Unknown weights initialization '%s'", name)
+	}
+}
+
+type randomInitOptions struct {
+	method WeightsInit
+	seed   int64
+}
+
+// WithRandomInit Initializes network randomly instead of reading weights file (weightsFile argument of NewYoloV3 is ignored)
+/*
+	Kernels of convolution layers are drawn by given method, biases are zero and batch normalization is identity
+	transformation. Useful for training of new architectures from scratch. Can't be combined with WithMappedWeights().
+	Trainer of such network uses the same seed (see WithSeed()).
+*/
+func WithRandomInit(method WeightsInit, seed int64) ModelOption {
+	return func(opts *modelOptions) {
+		opts.randomInit = &randomInitOptions{
+			method: method,
+			seed:   seed,
+		}
+	}
+}
+
+// randomWeights Returns darknet weights (with header) which match configuration and are initialized by given method
+func randomWeights(blocks []map[string]string, method WeightsInit, seed int64) ([]float32, error) {
+	if len(blocks) < 2 {
+		return nil, fmt.Errorf("Configuration should contain network parameters and at least one layer")
+	}
+	channels, err := InputChannels(blocks[0])
+	if err != nil {
+		return nil, err
+	}
+	convs, err := traceChannels(&channelGraph{}, blocks[1:], channels, math.MaxInt32)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't prepare layers of configuration")
+	}
+	// Header: major, minor, revision and 'seen' counter
+	data := []float32{0, math.Float32frombits(2), 0, 0, 0}
+	rng := rand.New(rand.NewSource(seed))
+	identityGamma := math32.Sqrt(1 + batchNormEpsilon)
+	for _, conv := range convs {
+		fanIn := len(conv.inputIDs) * conv.kernelSize * conv.kernelSize
+		fanOut := conv.filters * conv.kernelSize * conv.kernelSize
+		data = append(data, make([]float32, conv.filters)...)
+		if conv.batchNormalize {
+			for range conv.outputIDs {
+				data = append(data, identityGamma)
+			}
+			data = append(data, make([]float32, conv.filters)...)
+			for range conv.outputIDs {
+				data = append(data, 1)
+			}
+		}
+		switch method {
+		case InitHeNormal:
+			std := math.Sqrt(2.0 / float64(fanIn))
+			for i := 0; i < conv.filters*fanIn; i++ {
+				data = append(data, float32(rng.NormFloat64()*std))
+			}
+		case InitXavier, InitDarknetUniform:
+			limit := math.Sqrt(2.0 / float64(fanIn))
+			if method == InitXavier {
+				limit = math.Sqrt(6.0 / float64(fanIn+fanOut))
+			}
+			for i := 0; i < conv.filters*fanIn; i++ {
+				data = append(data, float32((2*rng.Float64()-1)*limit))
+			}
+		default:
+			return nil, fmt.Errorf("Unknown weights initialization %d", method)
+		}
+	}
+	return data, nil
+}
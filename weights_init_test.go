@@ -0,0 +1,54 @@
+package yologo
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomInit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yolo_init")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	weightsFile := filepath.Join(dir, "micro.weights")
+	err = WriteSyntheticWeights("./test_network_data/yolov3-micro.cfg", weightsFile, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// He-normal initialization is the same as synthetic weights file for the same seed
+	loaded := newMicroModel(t, weightsFile)
+	defer loaded.Close()
+	he := newMicroModel(t, "", WithRandomInit(InitHeNormal, 3))
+	defer he.Close()
+	if !assert.Len(t, he.LearningNodes, len(loaded.LearningNodes)) {
+		return
+	}
+	for i := range he.LearningNodes {
+		assert.Equal(t, loaded.LearningNodes[i].Value().Data(), he.LearningNodes[i].Value().Data())
+	}
+
+	// Uniform initializations stay in their ranges: the first layer has fan_in = 3*3*3 and fan_out = 8*3*3
+	xavier := newMicroModel(t, "", WithRandomInit(InitXavier, 3))
+	defer xavier.Close()
+	darknet := newMicroModel(t, "", WithRandomInit(InitDarknetUniform, 3))
+	defer darknet.Close()
+	xavierLimit := float32(math.Sqrt(6.0 / (27 + 72)))
+	darknetLimit := float32(math.Sqrt(2.0 / 27))
+	for i, w := range xavier.LearningNodes[0].Value().Data().([]float32) {
+		assert.True(t, w >= -xavierLimit && w <= xavierLimit)
+		assert.NotEqual(t, w, darknet.LearningNodes[0].Value().Data().([]float32)[i])
+	}
+	for _, w := range darknet.LearningNodes[0].Value().Data().([]float32) {
+		assert.True(t, w >= -darknetLimit && w <= darknetLimit)
+	}
+
+	_, err = ParseWeightsInit("orthogonal")
+	assert.Error(t, err)
+}
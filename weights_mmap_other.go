@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package yologo
+
+import (
+	"fmt"
+)
+
+// mapWeights Memory mapping of weights file is not supported on this platform
+func mapWeights(fname string) ([]float32, func() error, error) {
+	return nil, nil, fmt.Errorf("Memory-mapped weights are not supported on this platform")
+}
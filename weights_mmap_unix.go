@@ -0,0 +1,51 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package yologo
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// mapWeights Maps darknet weights file into memory and returns its content as []float32 without copying
+/*
+	Mapping is private and copy-on-write: pages which are never modified are shared between every process mapping the same file,
+	while modified ones (e.g. by denormalization of weights or by training) are copied.
+	Returned function releases the mapping; slice must not be used after that.
+*/
+func mapWeights(fname string) ([]float32, func() error, error) {
+	if !isLittleEndian() {
+		return nil, nil, fmt.Errorf("Memory-mapped weights are supported on little-endian hosts only")
+	}
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	size := info.Size()
+	if size == 0 || size%4 != 0 || int64(int(size)) != size {
+		return nil, nil, fmt.Errorf("Wrong size of weights file: %d bytes", size)
+	}
+	mapped, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, nil, err
+	}
+	data := unsafe.Slice((*float32)(unsafe.Pointer(&mapped[0])), len(mapped)/4)
+	unmap := func() error {
+		return syscall.Munmap(mapped)
+	}
+	return data, unmap, nil
+}
+
+// isLittleEndian Checks byte order of host
+func isLittleEndian() bool {
+	probe := uint16(1)
+	return *(*byte)(unsafe.Pointer(&probe)) == 1
+}
@@ -0,0 +1,59 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package yologo
+
+import (
+	"image"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMappedWeights(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yolo_mmap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	weightsFile := filepath.Join(dir, "micro.weights")
+	// Test weights have non-trivial batch normalization, so mapped pages are modified by folding
+	writeTestWeights(t, "./test_network_data/yolov3-micro.cfg", weightsFile, 1)
+	original, err := ioutil.ReadFile(weightsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	rng.Read(img.Pix)
+	detect := func(options ...ModelOption) Detections {
+		model := newMicroModel(t, weightsFile, options...)
+		defer model.Close()
+		detector, err := NewDetector(model, []string{"first", "second"}, 0, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer detector.Close()
+		dets, err := detector.Detect(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dets
+	}
+	read := detect()
+	mapped := detect(WithMappedWeights())
+	assert.NotEmpty(t, read)
+	assert.Equal(t, read, mapped, "Mapped and read weights should give the same detections")
+
+	// Mapping is private: modifications of weights are not written back to file
+	afterMapping, err := ioutil.ReadFile(weightsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, original, afterMapping)
+}
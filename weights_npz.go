@@ -0,0 +1,191 @@
+package yologo
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gorgonia.org/tensor"
+)
+
+const (
+	npzWeightSuffix = ".weight"
+	npzBiasSuffix   = ".bias"
+)
+
+// SaveNpz Saves parameters of every convolutional layer into NumPy .npz archive
+/*
+	Each array is named after layer's node: 'conv_3.weight' contains kernels with shape [filters, channels, size, size]
+	and 'conv_3.bias' contains biases with shape [filters].
+	Note: batch normalization is already folded into kernels and biases, so there are no separate BN arrays.
+	Archive can be read via numpy.load(fname) and used for PyTorch ports (e.g. via torch.from_numpy).
+*/
+func (net *YOLOv3) SaveNpz(fname string) error {
+	file, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	archive := zip.NewWriter(file)
+	convs := net.convLayers()
+	for i := range convs {
+		conv := convs[i]
+		kernels := conv.convNode.Value()
+		kernelsData, ok := kernels.Data().([]float32)
+		if !ok {
+			return fmt.Errorf("Kernels of convolution layer #%d should be type of []float32", conv.layerIndex)
+		}
+		err = writeNpzEntry(archive, fmt.Sprintf("conv_%d%s", conv.layerIndex, npzWeightSuffix), kernelsData, kernels.Shape())
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Can't write kernels of convolution layer #%d", conv.layerIndex))
+		}
+		err = writeNpzEntry(archive, fmt.Sprintf("conv_%d%s", conv.layerIndex, npzBiasSuffix), conv.biases, tensor.Shape{len(conv.biases)})
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Can't write biases of convolution layer #%d", conv.layerIndex))
+		}
+	}
+	return archive.Close()
+}
+
+// LoadNpz Loads parameters of convolutional layers from NumPy .npz archive
+/*
+	Archive should follow naming of SaveNpz(). Arrays of Float32 and Float64 types are supported.
+	Layers which are missing in archive keep their current parameters; arrays which don't match any layer lead to error.
+*/
+func (net *YOLOv3) LoadNpz(fname string) error {
+	archive, err := zip.OpenReader(fname)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	convsByIndex := make(map[int]*convLayer)
+	convs := net.convLayers()
+	for i := range convs {
+		convsByIndex[convs[i].layerIndex] = convs[i]
+	}
+
+	for _, entry := range archive.File {
+		name := strings.TrimSuffix(entry.Name, ".npy")
+		layerIdx, suffix, err := parseNpzName(name)
+		if err != nil {
+			return err
+		}
+		conv, ok := convsByIndex[layerIdx]
+		if !ok {
+			return fmt.Errorf("Array '%s' doesn't match any convolution layer", name)
+		}
+		data, shp, err := readNpzEntry(entry)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Can't read array '%s'", name))
+		}
+		switch suffix {
+		case npzWeightSuffix:
+			kernels := conv.convNode.Value()
+			if !shp.Eq(kernels.Shape()) {
+				return fmt.Errorf("Array '%s' has shape %v, but convolution layer #%d expects %v", name, shp, layerIdx, kernels.Shape())
+			}
+			copy(kernels.Data().([]float32), data)
+		case npzBiasSuffix:
+			if len(shp) != 1 {
+				return fmt.Errorf("Array '%s' should be one-dimensional, but has shape %v", name, shp)
+			}
+			err = conv.setBiases(data)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("Can't set biases from array '%s'", name))
+			}
+		}
+	}
+	return nil
+}
+
+// parseNpzName Extracts layer index and parameter suffix from array name like 'conv_3.weight'
+func parseNpzName(name string) (int, string, error) {
+	dot := strings.LastIndex(name, ".")
+	if dot < 0 || !strings.HasPrefix(name, "conv_") {
+		return -1, "", fmt.Errorf("Array name '%s' should look like 'conv_<layer>.weight' or 'conv_<layer>.bias'", name)
+	}
+	suffix := name[dot:]
+	if suffix != npzWeightSuffix && suffix != npzBiasSuffix {
+		return -1, "", fmt.Errorf("Unknown parameter '%s' for array '%s'", suffix, name)
+	}
+	layerIdx, err := strconv.Atoi(name[len("conv_"):dot])
+	if err != nil {
+		return -1, "", errors.Wrap(err, fmt.Sprintf("Can't parse layer index of array '%s'", name))
+	}
+	return layerIdx, suffix, nil
+}
+
+func writeNpzEntry(archive *zip.Writer, name string, data []float32, shp tensor.Shape) error {
+	// numpy.savez() stores arrays without compression
+	w, err := archive.CreateHeader(&zip.FileHeader{Name: name + ".npy", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	return writeNpyF32(w, data, shp)
+}
+
+// writeNpyF32 Writes little-endian float32 array in NumPy .npy format (version 1.0)
+// (*tensor.Dense).WriteNpy() is not used since it writes one-dimensional shapes as '(N)' which numpy can't read
+func writeNpyF32(w io.Writer, data []float32, shp tensor.Shape) error {
+	dims := make([]string, len(shp))
+	for i := range shp {
+		dims[i] = strconv.Itoa(shp[i])
+	}
+	shapeStr := strings.Join(dims, ", ")
+	if len(shp) == 1 {
+		shapeStr += ","
+	}
+	header := fmt.Sprintf("{'descr': '<f4', 'fortran_order': False, 'shape': (%s), }", shapeStr)
+	// Magic string, version and header length take 10 bytes; whole header should be terminated by newline and aligned to 16 bytes
+	padding := 16 - (10+len(header)+1)%16
+	if padding == 16 {
+		padding = 0
+	}
+	header = header + strings.Repeat(" ", padding) + "\n"
+
+	buf := make([]byte, 10+len(header)+4*len(data))
+	copy(buf, "\x93NUMPY")
+	buf[6], buf[7] = 1, 0
+	binary.LittleEndian.PutUint16(buf[8:10], uint16(len(header)))
+	copy(buf[10:], header)
+	offset := 10 + len(header)
+	for i := range data {
+		binary.LittleEndian.PutUint32(buf[offset+4*i:], math.Float32bits(data[i]))
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+func readNpzEntry(entry *zip.File) ([]float32, tensor.Shape, error) {
+	r, err := entry.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+	t := tensor.New(tensor.Of(tensor.Float32))
+	err = t.ReadNpy(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Typed getters are used since (*tensor.Dense).Data() returns single element for one-dimensional arrays read via ReadNpy()
+	switch t.Dtype() {
+	case tensor.Float32:
+		return t.Float32s(), t.Shape().Clone(), nil
+	case tensor.Float64:
+		data := t.Float64s()
+		dataF32 := make([]float32, len(data))
+		for i := range data {
+			dataF32[i] = float32(data[i])
+		}
+		return dataF32, t.Shape().Clone(), nil
+	default:
+		return nil, nil, fmt.Errorf("Only Float32/Float64 arrays are supported, but got %v", t.Dtype())
+	}
+}
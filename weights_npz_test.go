@@ -0,0 +1,183 @@
+package yologo
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorgonia.org/gorgonia"
+	"gorgonia.org/tensor"
+)
+
+// writeTestWeights Writes darknet weights file for given configuration filled with random values
+/*
+	Unlike real weights every layer gets non-zero biases and non-trivial batch normalization, so folding of parameters matters.
+*/
+func writeTestWeights(t *testing.T, cfgFile, weightsFile string, seed int64) {
+	blocks, err := ParseConfiguration(cfgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	channels := 3
+	if channelsStr, ok := blocks[0]["channels"]; ok {
+		channels, err = strconv.Atoi(channelsStr)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	atoi := func(block map[string]string, key string) int {
+		v, err := strconv.Atoi(strings.TrimSpace(block[key]))
+		if err != nil {
+			t.Fatalf("Wrong '%s' of block '%s': %s", key, block["type"], err.Error())
+		}
+		return v
+	}
+	// Header: major, minor, revision and 'seen' counter
+	data := []float32{0, math.Float32frombits(2), 0, 0, 0}
+	rng := rand.New(rand.NewSource(seed))
+	uniform := func(n int, from, to float32) {
+		for i := 0; i < n; i++ {
+			data = append(data, from+(to-from)*rng.Float32())
+		}
+	}
+	outputs := []int{}
+	prev := channels
+	for i, block := range blocks[1:] {
+		switch block["type"] {
+		case "convolutional":
+			filters, size := atoi(block, "filters"), atoi(block, "size")
+			// Biases, then gammas, means and variances of batch normalization
+			uniform(filters, -0.5, 0.5)
+			if block["batch_normalize"] == "1" {
+				uniform(filters, 0.5, 1.5)
+				uniform(filters, -0.2, 0.2)
+				uniform(filters, 0.5, 1.5)
+			}
+			std := math.Sqrt(2.0 / float64(prev*size*size))
+			for j := 0; j < filters*prev*size*size; j++ {
+				data = append(data, float32(rng.NormFloat64()*std))
+			}
+			prev = filters
+		case "route":
+			prev = 0
+			for _, layer := range strings.Split(block["layers"], ",") {
+				idx, err := strconv.Atoi(strings.TrimSpace(layer))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if idx < 0 {
+					idx += i
+				}
+				prev += outputs[idx]
+			}
+		}
+		outputs = append(outputs, prev)
+	}
+	file, err := os.Create(weightsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	err = binary.Write(file, binary.LittleEndian, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newMicroModel Builds micro network of tests (see test_network_data/yolov3-micro.cfg)
+func newMicroModel(t *testing.T, weightsFile string, options ...ModelOption) *YOLOv3 {
+	g := gorgonia.NewGraph()
+	input := gorgonia.NewTensor(g, tensor.Float32, 4, gorgonia.WithShape(1, 3, 32, 32), gorgonia.WithName("input"))
+	model, err := NewYoloV3(g, input, 2, 3, 0.1, "./test_network_data/yolov3-micro.cfg", weightsFile, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return model
+}
+
+func TestNpzRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yolo_npz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "micro.npz")
+	sourceWeights := filepath.Join(dir, "source.weights")
+	writeTestWeights(t, "./test_network_data/yolov3-micro.cfg", sourceWeights, 1)
+	targetWeights := filepath.Join(dir, "target.weights")
+	writeTestWeights(t, "./test_network_data/yolov3-micro.cfg", targetWeights, 2)
+
+	source := newMicroModel(t, sourceWeights)
+	err = source.SaveNpz(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every array is valid .npy file: header is aligned to 16 bytes, one-dimensional shape has trailing comma
+	archive, err := zip.OpenReader(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range archive.File {
+		r, err := entry.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "\x93NUMPY\x01\x00", string(content[:8]), "Wrong magic string of '%s'", entry.Name)
+		headerLen := int(binary.LittleEndian.Uint16(content[8:10]))
+		assert.Equal(t, 0, (10+headerLen)%16, "Header of '%s' should be aligned to 16 bytes", entry.Name)
+		header := string(content[10 : 10+headerLen])
+		assert.True(t, strings.HasSuffix(header, "\n"), "Header of '%s' should end with newline", entry.Name)
+		if strings.HasSuffix(entry.Name, npzBiasSuffix+".npy") {
+			assert.Contains(t, header, ",), }", "One-dimensional shape of '%s' should have trailing comma", entry.Name)
+		}
+	}
+	archive.Close()
+
+	target := newMicroModel(t, targetWeights)
+	err = target.LoadNpz(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceConvs, targetConvs := source.convLayers(), target.convLayers()
+	if !assert.Len(t, targetConvs, len(sourceConvs)) {
+		return
+	}
+	for i := range sourceConvs {
+		assert.Equal(t, sourceConvs[i].convNode.Value().(*tensor.Dense).Float32s(), targetConvs[i].convNode.Value().(*tensor.Dense).Float32s(), "Kernels of layer #%d", sourceConvs[i].layerIndex)
+		assert.Equal(t, sourceConvs[i].biases, targetConvs[i].biases, "Biases of layer #%d", sourceConvs[i].layerIndex)
+	}
+
+	// Archive of network with another number of classes doesn't fit (last layer has different number of filters)
+	cfg, err := ioutil.ReadFile("./test_network_data/yolov3-micro.cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCfg := filepath.Join(dir, "other.cfg")
+	err = ioutil.WriteFile(otherCfg, []byte(strings.Replace(strings.Replace(string(cfg), "filters=21", "filters=18", 1), "classes=2", "classes=1", 1)), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherWeights := filepath.Join(dir, "other.weights")
+	writeTestWeights(t, otherCfg, otherWeights, 3)
+	g := gorgonia.NewGraph()
+	input := gorgonia.NewTensor(g, tensor.Float32, 4, gorgonia.WithShape(1, 3, 32, 32), gorgonia.WithName("input"))
+	other, err := NewYoloV3(g, input, 1, 3, 0.1, otherCfg, otherWeights)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Error(t, other.LoadNpz(fname))
+}
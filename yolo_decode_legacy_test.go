@@ -0,0 +1,189 @@
+package yologo
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/chewxy/math32"
+	"github.com/pkg/errors"
+	"gorgonia.org/tensor"
+)
+
+// evaluateYOLOF32Legacy Previous implementation of YOLO decoding via tensor slicing (kept for comparison with the current one)
+func (op *yoloOp) evaluateYOLOF32Legacy(input tensor.Tensor, batchSize, stride, grid, bboxAttrs, numAnchors int, currentAnchors []float32) (retVal tensor.Tensor, err error) {
+
+	// Activation of x, y via sigmoid function
+	slXY, err := input.Slice(nil, nil, Slice(0, 2))
+	_, err = slXY.Apply(_sigmoidf32, tensor.UseUnsafe())
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't activate XY")
+	}
+
+	// Activation of classes (objects) via sigmoid function
+	slClasses, err := input.Slice(nil, nil, Slice(4, 5+op.numClasses))
+	_, err = slClasses.Apply(_sigmoidf32, tensor.UseUnsafe())
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't activate classes")
+	}
+
+	step := grid * numAnchors
+	for i := 0; i < grid; i++ {
+
+		vy, err := input.Slice(nil, Slice(i*step, i*step+step), Slice(1))
+		if err != nil {
+			return nil, errors.Wrap(err, "Can't slice while doing steps for grid")
+		}
+
+		_, err = tensor.Add(vy, float32(i), tensor.UseUnsafe())
+		if err != nil {
+			return nil, errors.Wrap(err, "Can't do tensor.Add(...) for float32; (1)")
+		}
+
+		for n := 0; n < numAnchors; n++ {
+			anchorsSlice, err := input.Slice(nil, Slice(i*numAnchors+n, input.Shape()[1], step), Slice(0))
+			if err != nil {
+				return nil, errors.Wrap(err, "Can't slice anchors while doing steps for grid")
+			}
+			_, err = tensor.Add(anchorsSlice, float32(i), tensor.UseUnsafe())
+			if err != nil {
+				return nil, errors.Wrap(err, "Can't do tensor.Add(...) for float32; (1)")
+			}
+		}
+
+	}
+
+	anchors := []float32{}
+	for i := 0; i < grid*grid; i++ {
+		anchors = append(anchors, currentAnchors...)
+	}
+
+	anchorsTensor := tensor.New(tensor.Of(tensor.Float32), tensor.WithShape(1, grid*grid*numAnchors, 2))
+	for i := range anchors {
+		anchorsTensor.Set(i, anchors[i])
+	}
+
+	_, err = tensor.Div(anchorsTensor, float32(stride), tensor.UseUnsafe())
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't do tensor.Div(...) for float32")
+	}
+
+	vhw, err := input.Slice(nil, nil, Slice(2, 4))
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't do slice on input S(2,4)")
+	}
+
+	_, err = vhw.Apply(math32.Exp, tensor.UseUnsafe())
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't apply exp32 to YOLO operation")
+	}
+
+	_, err = tensor.Mul(vhw, anchorsTensor, tensor.UseUnsafe())
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't do tensor.Mul(...) for anchors")
+	}
+
+	vv, err := input.Slice(nil, nil, Slice(0, 4))
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't do slice on input S(0,4)")
+	}
+
+	_, err = tensor.Mul(vv, float32(stride), tensor.UseUnsafe())
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't do tensor.Mul(...) for float32")
+	}
+
+	return input, nil
+}
+
+// randomYoloInput Returns reshaped YOLO input (see prepareReshapedInput()) with values in range [-4; 4)
+func randomYoloInput(t *testing.T, seed int64, batchSize, grid, bboxAttrs, numAnchors int) *tensor.Dense {
+	rng := rand.New(rand.NewSource(seed))
+	data := make([]float32, batchSize*bboxAttrs*numAnchors*grid*grid)
+	for i := range data {
+		data[i] = 8*rng.Float32() - 4
+	}
+	input := tensor.New(tensor.WithShape(batchSize, bboxAttrs*numAnchors, grid, grid), tensor.WithBacking(data))
+	if err := prepareReshapedInput(input, batchSize, grid, bboxAttrs, numAnchors); err != nil {
+		t.Fatal(err)
+	}
+	return input
+}
+
+func TestYoloDecodeMatchesLegacy(t *testing.T) {
+	anchors := []float32{10, 14, 23, 27, 37, 58}
+	numClasses, numAnchors, grid, stride := 3, 3, 5, 32
+	bboxAttrs := 5 + numClasses
+	op := newYoloOp(anchors, []int{0, 1, 2}, grid*stride, grid, numClasses, 0.7)
+	for _, batchSize := range []int{1, 4} {
+		input := randomYoloInput(t, int64(batchSize), batchSize, grid, bboxAttrs, numAnchors)
+		sampleSize := grid * grid * numAnchors * bboxAttrs
+		source := append([]float32{}, input.Float32s()...)
+
+		// Legacy implementation decodes single sample at a time
+		expected := make([]float32, 0, len(source))
+		for b := 0; b < batchSize; b++ {
+			sample := tensor.New(tensor.WithShape(1, grid*grid*numAnchors, bboxAttrs), tensor.WithBacking(append([]float32{}, source[b*sampleSize:(b+1)*sampleSize]...)))
+			decoded, err := op.evaluateYOLOF32Legacy(sample, 1, stride, grid, bboxAttrs, numAnchors, anchors)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected = append(expected, decoded.(*tensor.Dense).Float32s()...)
+		}
+
+		// Samples of batch are decoded in parallel
+		decoded, err := op.evaluateYOLOF32(input, batchSize, stride, grid, bboxAttrs, numAnchors, anchors)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertFloat32sClose(t, expected, decoded.(*tensor.Dense).Float32s(), 1e-6, "Decoded output differs from legacy one for batch size %d", batchSize)
+	}
+}
+
+func BenchmarkYoloDecode(b *testing.B) {
+	heads := []struct {
+		grid    int
+		anchors []float32
+	}{
+		{13, []float32{116, 90, 156, 198, 373, 326}},
+		{26, []float32{30, 61, 62, 45, 59, 119}},
+		{52, []float32{10, 13, 16, 30, 33, 23}},
+	}
+	for _, head := range heads {
+		op := newYoloOp(head.anchors, []int{0, 1, 2}, 416, head.grid, 80, 0.7)
+		stride := 416 / head.grid
+		prepare := func() tensor.Tensor {
+			input := benchTensor(1, 255, head.grid, head.grid)
+			if err := prepareReshapedInput(input, 1, head.grid, 85, 3); err != nil {
+				b.Fatal(err)
+			}
+			return input
+		}
+		implementations := []struct {
+			name     string
+			evaluate func(tensor.Tensor) (tensor.Tensor, error)
+		}{
+			{"Legacy", func(in tensor.Tensor) (tensor.Tensor, error) {
+				return op.evaluateYOLOF32Legacy(in, 1, stride, head.grid, 85, 3, head.anchors)
+			}},
+			{"Vectorized", func(in tensor.Tensor) (tensor.Tensor, error) {
+				return op.evaluateYOLOF32(in, 1, stride, head.grid, 85, 3, head.anchors)
+			}},
+		}
+		for _, impl := range implementations {
+			b.Run(fmt.Sprintf("%s/grid%d", impl.name, head.grid), func(b *testing.B) {
+				input := prepare()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					// Decoding is done in place
+					b.StopTimer()
+					in := input.Clone().(tensor.Tensor)
+					b.StartTimer()
+					if _, err := impl.evaluate(in); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -46,6 +46,9 @@ func (op *yoloDiffOp) Do(inputs ...gorgonia.Value) (gorgonia.Value, error) {
 	if op.training.bboxes == nil {
 		return nil, fmt.Errorf("Training parameter 'bboxes' for yoloOp were not set")
 	}
+	if op.training.active == nil {
+		return nil, fmt.Errorf("Training parameter 'active' for yoloOp were not set")
+	}
 
 	in := inputs[0]
 	output := inputs[1]
@@ -54,7 +57,7 @@ func (op *yoloDiffOp) Do(inputs ...gorgonia.Value) (gorgonia.Value, error) {
 	case tensor.Float32:
 		inGradData := inGrad.Data().([]float32)
 		outGradData := output.Data().([]float32)
-		op.f32(inGradData, outGradData, op.training.scales, op.training.inputs, op.training.targets, op.training.bboxes)
+		op.f32(inGradData, outGradData, op.training.scales, op.training.inputs, op.training.targets, op.training.bboxes, op.training.active)
 		break
 	case tensor.Float64:
 		return nil, fmt.Errorf("yoloDiffOp for Float64 is not implemented yet")
@@ -103,16 +106,20 @@ func (op *yoloOp) SymDiff(inputs gorgonia.Nodes, output, grad *gorgonia.Node) (r
 
 /* Unexported methods */
 
-func (op *yoloDiffOp) f32(inGradData, outGradData, scales, inputs, targets, bboxes []float32) {
+// f32 Evaluates gradients of inputs, entries which don't contribute to cost function (see prepareTrainingOutputF32()) get zero
+func (op *yoloDiffOp) f32(inGradData, outGradData, scales, inputs, targets, bboxes []float32, active []bool) {
 	for i := range inGradData {
 		inGradData[i] = 0
 	}
 	for i := 0; i < len(outGradData); i = i + 5 + op.numClasses {
 		for j := 0; j < 4; j++ {
+			if !active[i+j] {
+				continue
+			}
 			inGradData[i+j] = outGradData[i+j] * (scales[i+j] * scales[i+j] * (inputs[i+j] - targets[i+j]))
 		}
 		for j := 4; j < 5+op.numClasses; j++ {
-			if outGradData[i+j] != 0 {
+			if active[i+j] && outGradData[i+j] != 0 {
 				if targets[i+j] == 0 {
 					inGradData[i+j] = outGradData[i+j] * (bboxes[i+j])
 				} else {
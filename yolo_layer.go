@@ -8,8 +8,10 @@ import (
 )
 
 type yoloLayer struct {
-	masks          []int
-	anchors        [][2]int
+	masks []int
+	// anchors Anchors selected by masks
+	anchors [][2]int
+	// flattenAnchors All anchors of network: [w_0, h_0, w_1, h_1, ...]
 	flattenAnchors []int
 	inputSize      int
 	classesNum     int
@@ -42,11 +44,7 @@ func (l *yoloLayer) ToNode(g *gorgonia.ExprGraph, input ...*gorgonia.Node) (*gor
 	for i := range flattenAnchorsF32 {
 		flattenAnchorsF32[i] = float32(l.flattenAnchors[i])
 	}
-	masksIdx := make([]int, len(l.masks))
-	for i := range l.masks {
-		masksIdx[i] = i
-	}
-	yoloNode, yoloTrainer, err := YOLOv3Node(inputN, flattenAnchorsF32, masksIdx, l.inputSize, l.classesNum, l.ignoreThresh)
+	yoloNode, yoloTrainer, err := YOLOv3Node(inputN, flattenAnchorsF32, l.masks, l.inputSize, l.classesNum, l.ignoreThresh)
 	l.yoloTrainer = yoloTrainer
 	if err != nil {
 		return nil, errors.Wrap(err, "Can't prepare YOLOv3 operation")
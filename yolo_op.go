@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"hash"
 	"hash/fnv"
+	"sync"
 
 	"github.com/chewxy/hm"
 	"github.com/chewxy/math32"
@@ -23,6 +24,8 @@ type yoloOp struct {
 	gridSize    int
 	bestAnchors [][]int
 	training    *yoloTraining
+
+	decoder *yoloDecoder
 }
 
 func newYoloOp(anchors []float32, masks []int, netSize, gridSize, numClasses int, ignoreTresh float32) *yoloOp {
@@ -42,8 +45,8 @@ func newYoloOp(anchors []float32, masks []int, netSize, gridSize, numClasses int
 // YOLOv3Node Constructor for YOLO-based node operation
 /*
 	input - Input node
-	anchors - Slice of anchors
-	masks - Slice of masks
+	anchors - Slice of all anchors of network: [w_0, h_0, w_1, h_1, ...]
+	masks - Indices of anchors which are used by this layer
 	netSize - Height/Width of input
 	numClasses - Amount of classes
 	ignoreTresh - Treshold
@@ -64,7 +67,7 @@ func (op *yoloOp) Arity() int {
 func (op *yoloOp) ReturnsPtr() bool  { return false }
 func (op *yoloOp) CallsExtern() bool { return false }
 func (op *yoloOp) WriteHash(h hash.Hash) {
-	fmt.Fprintf(h, "YOLO{}(anchors: (%v))", op.anchors)
+	fmt.Fprintf(h, "YOLO{}(anchors: (%v), masks: (%v))", op.anchors, op.masks)
 }
 func (op *yoloOp) Hashcode() uint32 {
 	h := fnv.New32a()
@@ -72,7 +75,7 @@ func (op *yoloOp) Hashcode() uint32 {
 	return h.Sum32()
 }
 func (op *yoloOp) String() string {
-	return fmt.Sprintf("YOLO{}(anchors: (%v))", op.anchors)
+	return fmt.Sprintf("YOLO{}(anchors: (%v), masks: (%v))", op.anchors, op.masks)
 }
 func (op *yoloOp) InferShape(inputs ...gorgonia.DimSizer) (tensor.Shape, error) {
 	shp := inputs[0].(tensor.Shape)
@@ -105,16 +108,17 @@ func (op *yoloOp) Do(inputs ...gorgonia.Value) (retVal gorgonia.Value, err error
 	gridSize := inputTensor.Shape()[2]
 	bboxAttributes := 5 + op.numClasses
 	numAnchors := len(op.anchors) / 2
-	currentAnchors := []float32{}
+	// Layer predicts boxes only for anchors which are selected by its mask
+	currentAnchors := make([]float32, 0, 2*len(op.masks))
 	for i := range op.masks {
-		if op.masks[i] >= numAnchors {
-			return nil, fmt.Errorf("Num of anchors is %[1]d, but mask values is %[2]d > %[1]d", numAnchors, op.masks[i])
+		if op.masks[i] < 0 || op.masks[i] >= numAnchors {
+			return nil, fmt.Errorf("Num of anchors is %[1]d, but mask value is %[2]d (should be in range [0; %[1]d))", numAnchors, op.masks[i])
 		}
-		currentAnchors = append(currentAnchors, op.anchors[i*2], op.anchors[i*2+1])
+		currentAnchors = append(currentAnchors, op.anchors[op.masks[i]*2], op.anchors[op.masks[i]*2+1])
 	}
 
 	// Prepare reshaped input (it's common for both training and detection mode)
-	err = prepareReshapedInput(inputTensor, batchSize, gridSize, bboxAttributes, numAnchors)
+	err = prepareReshapedInput(inputTensor, batchSize, gridSize, bboxAttributes, len(op.masks))
 	if err != nil {
 		return nil, errors.Wrap(err, "Can't prepare reshaped input")
 	}
@@ -143,12 +147,14 @@ func (op *yoloOp) Do(inputs ...gorgonia.Value) (retVal gorgonia.Value, err error
 		return nil, errors.Wrap(err, "Can't cast tensor to []float32 for bboxes [Training mode]")
 	}
 
-	preparedYOLOout := prepareTrainingOutputF32(
+	preparedYOLOout, active := prepareTrainingOutputF32(
 		op.training.inputs, op.training.bboxes,
-		op.training.targets, op.training.scales,
+		op.training.targets, op.training.scales, op.training.truths,
 		op.bestAnchors, op.masks,
 		op.numClasses, op.dimensions, op.gridSize, op.ignoreTresh,
 	)
+	op.training.active = active
+	op.training.losses = lossesF32(preparedYOLOout, op.training.targets, active, op.numClasses)
 
 	yoloTrainingTensor := tensor.New(tensor.WithShape(1, op.gridSize*op.gridSize*len(op.masks), 5+op.numClasses), tensor.Of(tensor.Float32), tensor.WithBacking(preparedYOLOout))
 
@@ -197,87 +203,105 @@ func prepareReshapedInput(input tensor.Tensor, batchSize, grid, bboxAttrs, numAn
 	return nil
 }
 
+// evaluateYOLOF32 Decodes reshaped YOLO input [batch, grid*grid*anchors, bbox attributes] in place
+/*
+	x, y = (sigmoid(tx, ty) + cell offset) * stride
+	w, h = exp(tw, th) * (anchor / stride) * stride
+	objectness and classes = sigmoid(...)
+	Samples of batch are decoded in parallel.
+*/
 func (op *yoloOp) evaluateYOLOF32(input tensor.Tensor, batchSize, stride, grid, bboxAttrs, numAnchors int, currentAnchors []float32) (retVal tensor.Tensor, err error) {
-
-	// Activation of x, y via sigmoid function
-	slXY, err := input.Slice(nil, nil, Slice(0, 2))
-	_, err = slXY.Apply(_sigmoidf32, tensor.UseUnsafe())
-	if err != nil {
-		return nil, errors.Wrap(err, "Can't activate XY")
+	dense, ok := input.(*tensor.Dense)
+	if !ok || dense.Dtype() != tensor.Float32 {
+		return nil, fmt.Errorf("Only dense Float32 tensors supported for YOLO decoding, but got %v", input.Dtype())
 	}
-
-	// Activation of classes (objects) via sigmoid function
-	slClasses, err := input.Slice(nil, nil, Slice(4, 5+op.numClasses))
-	_, err = slClasses.Apply(_sigmoidf32, tensor.UseUnsafe())
-	if err != nil {
-		return nil, errors.Wrap(err, "Can't activate classes")
+	// Float32s() is used instead of Data(), since the latter may return stale view of tensor read via ReadNpy()
+	data := dense.Float32s()
+	sampleSize := grid * grid * numAnchors * bboxAttrs
+	if len(data) != batchSize*sampleSize {
+		return nil, fmt.Errorf("YOLO decoding expects %d values, but got %d", batchSize*sampleSize, len(data))
 	}
-
-	step := grid * numAnchors
-	for i := 0; i < grid; i++ {
-
-		vy, err := input.Slice(nil, Slice(i*step, i*step+step), Slice(1))
-		if err != nil {
-			return nil, errors.Wrap(err, "Can't slice while doing steps for grid")
-		}
-
-		_, err = tensor.Add(vy, float32(i), tensor.UseUnsafe())
-		if err != nil {
-			return nil, errors.Wrap(err, "Can't do tensor.Add(...) for float32; (1)")
-		}
-
-		for n := 0; n < numAnchors; n++ {
-			anchorsSlice, err := input.Slice(nil, Slice(i*numAnchors+n, input.Shape()[1], step), Slice(0))
-			if err != nil {
-				return nil, errors.Wrap(err, "Can't slice anchors while doing steps for grid")
-			}
-			_, err = tensor.Add(anchorsSlice, float32(i), tensor.UseUnsafe())
-			if err != nil {
-				return nil, errors.Wrap(err, "Can't do tensor.Add(...) for float32; (1)")
-			}
-		}
-
+	decoder := op.prepareDecoder(stride, grid, currentAnchors)
+	if batchSize == 1 {
+		decoder.decode(data, bboxAttrs)
+		return input, nil
 	}
-
-	anchors := []float32{}
-	for i := 0; i < grid*grid; i++ {
-		anchors = append(anchors, currentAnchors...)
+	var wg sync.WaitGroup
+	for b := 0; b < batchSize; b++ {
+		wg.Add(1)
+		go func(sample []float32) {
+			defer wg.Done()
+			decoder.decode(sample, bboxAttrs)
+		}(data[b*sampleSize : (b+1)*sampleSize])
 	}
+	wg.Wait()
+	return input, nil
+}
 
-	anchorsTensor := tensor.New(tensor.Of(tensor.Float32), tensor.WithShape(1, grid*grid*numAnchors, 2))
-	for i := range anchors {
-		anchorsTensor.Set(i, anchors[i])
+// prepareDecoder Returns decoder for given grid and anchors (it is precomputed once and reused while they are the same)
+/*
+	Operation is executed by single VM at a time (as node values are), so cache doesn't need synchronization.
+*/
+func (op *yoloOp) prepareDecoder(stride, grid int, anchors []float32) *yoloDecoder {
+	if op.decoder == nil || !op.decoder.matches(stride, grid, anchors) {
+		op.decoder = newYoloDecoder(stride, grid, anchors)
 	}
+	return op.decoder
+}
 
-	_, err = tensor.Div(anchorsTensor, float32(stride), tensor.UseUnsafe())
-	if err != nil {
-		return nil, errors.Wrap(err, "Can't do tensor.Div(...) for float32")
-	}
+// yoloDecoder Precomputed parameters of YOLO output decoding for single grid
+type yoloDecoder struct {
+	stride int
+	grid   int
+	// anchors Source anchors: [w_0, h_0, w_1, h_1, ...]
+	anchors []float32
+	// scaledAnchors Anchors divided by stride
+	scaledAnchors []float32
+}
 
-	vhw, err := input.Slice(nil, nil, Slice(2, 4))
-	if err != nil {
-		return nil, errors.Wrap(err, "Can't do slice on input S(2,4)")
+func newYoloDecoder(stride, grid int, anchors []float32) *yoloDecoder {
+	decoder := &yoloDecoder{
+		stride:        stride,
+		grid:          grid,
+		anchors:       append([]float32{}, anchors...),
+		scaledAnchors: make([]float32, len(anchors)),
 	}
-
-	_, err = vhw.Apply(math32.Exp, tensor.UseUnsafe())
-	if err != nil {
-		return nil, errors.Wrap(err, "Can't apply exp32 to YOLO operation")
+	for i := range anchors {
+		decoder.scaledAnchors[i] = anchors[i] / float32(stride)
 	}
+	return decoder
+}
 
-	_, err = tensor.Mul(vhw, anchorsTensor, tensor.UseUnsafe())
-	if err != nil {
-		return nil, errors.Wrap(err, "Can't do tensor.Mul(...) for anchors")
+func (d *yoloDecoder) matches(stride, grid int, anchors []float32) bool {
+	if d.stride != stride || d.grid != grid || len(d.anchors) != len(anchors) {
+		return false
 	}
-
-	vv, err := input.Slice(nil, nil, Slice(0, 4))
-	if err != nil {
-		return nil, errors.Wrap(err, "Can't do slice on input S(0,4)")
+	for i := range anchors {
+		if d.anchors[i] != anchors[i] {
+			return false
+		}
 	}
+	return true
+}
 
-	_, err = tensor.Mul(vv, float32(stride), tensor.UseUnsafe())
-	if err != nil {
-		return nil, errors.Wrap(err, "Can't do tensor.Mul(...) for float32")
+// decode Decodes single sample [grid*grid*anchors, bbox attributes] in place
+func (d *yoloDecoder) decode(data []float32, bboxAttrs int) {
+	numAnchors := len(d.scaledAnchors) / 2
+	stride := float32(d.stride)
+	offset := 0
+	for cy := 0; cy < d.grid; cy++ {
+		for cx := 0; cx < d.grid; cx++ {
+			for a := 0; a < numAnchors; a++ {
+				bbox := data[offset : offset+bboxAttrs]
+				bbox[0] = (_sigmoidf32(bbox[0]) + float32(cx)) * stride
+				bbox[1] = (_sigmoidf32(bbox[1]) + float32(cy)) * stride
+				bbox[2] = math32.Exp(bbox[2]) * d.scaledAnchors[2*a] * stride
+				bbox[3] = math32.Exp(bbox[3]) * d.scaledAnchors[2*a+1] * stride
+				for k := 4; k < bboxAttrs; k++ {
+					bbox[k] = _sigmoidf32(bbox[k])
+				}
+				offset += bboxAttrs
+			}
+		}
 	}
-
-	return input, nil
 }
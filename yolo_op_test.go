@@ -2,6 +2,8 @@ package yologo
 
 import (
 	"fmt"
+	"math"
+	"math/rand"
 	"os"
 	"testing"
 
@@ -10,76 +12,162 @@ import (
 	"gorgonia.org/tensor"
 )
 
-func TestYolo(t *testing.T) {
+// assertFloat32sClose Checks that slices are equal within tolerance which is relative to the largest absolute expected value
+func assertFloat32sClose(t *testing.T, expected, actual []float32, relTolerance float64, msgAndArgs ...interface{}) bool {
+	maxAbs := 1.0
+	for _, v := range expected {
+		maxAbs = math.Max(maxAbs, math.Abs(float64(v)))
+	}
+	return assert.InDeltaSlice(t, expected, actual, relTolerance*maxAbs, msgAndArgs...)
+}
 
+func TestYolo(t *testing.T) {
 	inputSize := 416
 	numClasses := 80
-	testAnchors := [][]float32{
-		[]float32{10, 13, 16, 30, 33, 23},
-		[]float32{30, 51, 62, 45, 59, 119},
-		[]float32{116, 90, 156, 198, 373, 326},
-	}
-
-	numpyInputs := []string{
-		"./test_yolo_op_data/1input.[(10, 13), (16, 30), (33, 23)].npy",
-		"./test_yolo_op_data/1input.[(30, 61), (62, 45), (59, 119)].npy",
-		"./test_yolo_op_data/1input.[(116, 90), (156, 198), (373, 326)].npy",
-	}
-
-	numpyExpectedOutputs := []string{
-		"./test_yolo_op_data/1output.[(10, 13), (16, 30), (33, 23)].npy",
-		"./test_yolo_op_data/1output.[(30, 61), (62, 45), (59, 119)].npy",
-		"./test_yolo_op_data/1output.[(116, 90), (156, 198), (373, 326)].npy",
+	// Every head is checked twice: with its own anchors only and with all anchors of network selected via mask
+	fullAnchors := []float32{10, 13, 16, 30, 33, 23, 30, 61, 62, 45, 59, 119, 116, 90, 156, 198, 373, 326}
+	heads := []struct {
+		anchors []float32
+		mask    []int
+		npyName string
+	}{
+		{[]float32{10, 13, 16, 30, 33, 23}, []int{0, 1, 2}, "[(10, 13), (16, 30), (33, 23)].npy"},
+		{[]float32{30, 61, 62, 45, 59, 119}, []int{0, 1, 2}, "[(30, 61), (62, 45), (59, 119)].npy"},
+		{[]float32{116, 90, 156, 198, 373, 326}, []int{0, 1, 2}, "[(116, 90), (156, 198), (373, 326)].npy"},
+		{fullAnchors, []int{0, 1, 2}, "[(10, 13), (16, 30), (33, 23)].npy"},
+		{fullAnchors, []int{3, 4, 5}, "[(30, 61), (62, 45), (59, 119)].npy"},
+		{fullAnchors, []int{6, 7, 8}, "[(116, 90), (156, 198), (373, 326)].npy"},
 	}
-
-	for i := range testAnchors {
-		// Read input values from numpy format
-		input := tensor.New(tensor.Of(tensor.Float32))
-		r, err := os.Open(numpyInputs[i])
+	readNpy := func(fname string) *tensor.Dense {
+		data := tensor.New(tensor.Of(tensor.Float32))
+		r, err := os.Open(fname)
 		if err != nil {
-			t.Error(err)
-			return
+			t.Fatal(err)
 		}
-		err = input.ReadNpy(r)
+		defer r.Close()
+		err = data.ReadNpy(r)
 		if err != nil {
-			t.Error(err)
-			return
+			t.Fatal(err)
 		}
+		return data
+	}
 
-		// Read expected values from numpy format
-		expected := tensor.New(tensor.Of(tensor.Float32))
-		r, err = os.Open(numpyExpectedOutputs[i])
-		if err != nil {
-			t.Error(err)
-			return
-		}
-		err = expected.ReadNpy(r)
-		if err != nil {
-			t.Error(err)
-			return
-		}
+	for _, head := range heads {
+		// Read input and expected values from numpy format
+		input := readNpy("./test_yolo_op_data/1input." + head.npyName)
+		expected := readNpy("./test_yolo_op_data/1output." + head.npyName)
 
-		// Load graph
+		// Prepare YOLOv3 node
 		g := gorgonia.NewGraph()
 		inputTensor := gorgonia.NewTensor(g, tensor.Float32, 4, gorgonia.WithShape(input.Shape()...), gorgonia.WithName("yolo"))
-		// Prepare YOLOv3 node
-		outNode, _, err := YOLOv3Node(inputTensor, testAnchors[i], []int{0, 1, 2}, inputSize, numClasses, 0.7)
+		outNode, _, err := YOLOv3Node(inputTensor, head.anchors, head.mask, inputSize, numClasses, 0.7)
 		if err != nil {
-			t.Error(err)
-			return
+			t.Fatal(err)
 		}
 		// Run operation
 		vm := gorgonia.NewTapeMachine(g)
 		if err := gorgonia.Let(inputTensor, input); err != nil {
-			t.Error(err)
-			return
+			t.Fatal(err)
+		}
+		if err := vm.RunAll(); err != nil {
+			t.Fatal(err)
 		}
-		vm.RunAll()
 		vm.Close()
 
 		// Check if everything is fine
-		if !assert.Equal(t, outNode.Value().Data(), expected.Data(), "Output is not equal to expected value") {
+		if !assert.Equal(t, expected.Shape(), outNode.Shape(), "Wrong output shape for anchors %v and mask %v", head.anchors, head.mask) {
+			continue
+		}
+		if !assertFloat32sClose(t, expected.Float32s(), outNode.Value().(*tensor.Dense).Float32s(), 1e-5, "Output is not equal to expected value for anchors %v and mask %v", head.anchors, head.mask) {
 			t.Error(fmt.Sprintf("Got: %v\nExpected: %v", outNode.Value(), expected))
 		}
 	}
 }
+
+func TestYoloBestAnchorsByMask(t *testing.T) {
+	anchors := []float32{10, 13, 16, 30, 33, 23, 30, 61, 62, 45, 59, 119, 116, 90, 156, 198, 373, 326}
+	// Object of exactly the same size as anchor #7 in the center of image
+	target := []float32{3, 0.5, 0.5, 156.0 / 416.0, 198.0 / 416.0}
+
+	bestAnchors := getBestAnchorsF32(target, anchors, []int{6, 7, 8}, 416, 13)
+	assert.Equal(t, []int{1, 6, 6}, bestAnchors[0], "Object should be assigned to the second anchor of head with mask [6, 7, 8]")
+
+	bestAnchors = getBestAnchorsF32(target, anchors, []int{0, 1, 2}, 416, 52)
+	assert.Equal(t, -1, bestAnchors[0][0], "Object should not be assigned to head with mask [0, 1, 2]")
+
+	// Head which doesn't own best anchor of object should not get any target
+	op := newYoloOp(anchors, []int{0, 1, 2}, 416, 52, 80, 0.7)
+	op.SetTarget(target)
+	for i := range op.training.targets {
+		if op.training.targets[i] != 0 {
+			t.Fatalf("Target #%d should be zero for head which doesn't own best anchor, but got %f", i, op.training.targets[i])
+		}
+	}
+}
+
+func TestYoloIgnoreMask(t *testing.T) {
+	anchors := []float32{10, 14, 23, 27, 37, 58}
+	masks := []int{0, 1, 2}
+	// Number of entries of output isn't a multiple of 5 for two classes
+	gridSize, numClasses := 13, 2
+	bboxAttrs := 5 + numClasses
+	op := newYoloOp(anchors, masks, 416, gridSize, numClasses, 0.5)
+	truth := []float32{1, 0.25, 0.25, 0.2, 0.2}
+	op.SetTarget(truth)
+
+	size := gridSize * gridSize * len(masks) * bboxAttrs
+	inputs := make([]float32, size)
+	bboxes := make([]float32, size)
+	for i := 0; i < size; i += bboxAttrs {
+		bboxes[i+4] = 0.5
+	}
+	// Box of the first cell (object is assigned to another one) which overlaps object well and box far from object
+	overlapping, far := 0, size-bboxAttrs
+	copy(bboxes[overlapping:], []float32{0.25 * 416, 0.25 * 416, 0.2 * 416, 0.2 * 416})
+	copy(bboxes[far:], []float32{0.9 * 416, 0.9 * 416, 0.05 * 416, 0.05 * 416})
+
+	loss, active := prepareTrainingOutputF32(inputs, bboxes, op.training.targets, op.training.scales, op.training.truths, op.bestAnchors, masks, numClasses, 416, gridSize, op.ignoreTresh)
+	assert.False(t, active[overlapping+4], "Objectness of box which overlaps ground truth should be ignored")
+	assert.Equal(t, float32(0), loss[overlapping+4])
+	assert.True(t, active[far+4], "Objectness of box far from ground truth should contribute to cost")
+	assert.InDelta(t, bceLossF32(0, 0.5), loss[far+4], 1e-6)
+}
+
+func TestYoloNegativeTarget(t *testing.T) {
+	anchors := []float32{10, 14, 23, 27, 37, 58}
+	masks := []int{0, 1, 2}
+	gridSize, numClasses := 13, 2
+	bboxAttrs := 5 + numClasses
+	op := newYoloOp(anchors, masks, 416, gridSize, numClasses, 0.7)
+	op.SetTarget(nil)
+
+	rng := rand.New(rand.NewSource(1))
+	size := gridSize * gridSize * len(masks) * bboxAttrs
+	inputs := make([]float32, size)
+	bboxes := make([]float32, size)
+	for i := range inputs {
+		inputs[i] = rng.Float32()
+		bboxes[i] = rng.Float32()
+	}
+	loss, active := prepareTrainingOutputF32(inputs, bboxes, op.training.targets, op.training.scales, op.training.truths, op.bestAnchors, masks, numClasses, 416, gridSize, op.ignoreTresh)
+	outGrad := make([]float32, size)
+	for i := range outGrad {
+		outGrad[i] = 1
+	}
+	inGrad := make([]float32, size)
+	diff := &yoloDiffOp{*op}
+	diff.f32(inGrad, outGrad, op.training.scales, inputs, op.training.targets, bboxes, active)
+
+	// Image without objects contributes no-object loss of every box only
+	for i := range loss {
+		if i%bboxAttrs == 4 {
+			assert.True(t, active[i], "Objectness #%d should contribute to cost", i)
+			assert.InDelta(t, bceLossF32(0, bboxes[i]), loss[i], 1e-6)
+			assert.InDelta(t, bboxes[i], inGrad[i], 1e-6)
+			continue
+		}
+		assert.False(t, active[i], "Entry #%d should not contribute to cost", i)
+		assert.Equal(t, float32(0), loss[i])
+		assert.Equal(t, float32(0), inGrad[i])
+	}
+}
@@ -14,11 +14,58 @@ type YoloTrainer interface {
 	SetTarget([]float32)
 }
 
+// LossReporter YoloTrainer which reports components of its cost function (yoloOP implements it)
+type LossReporter interface {
+	// Losses Returns components of cost function evaluated by the last forward pass in training mode
+	Losses() Losses
+}
+
+// Losses Components of cost function of YOLO layers
+type Losses struct {
+	// Coordinates MSE loss of coordinates of boxes which are assigned to objects
+	Coordinates float32
+	// Objectness BCE loss of objectness of boxes which are assigned to objects
+	Objectness float32
+	// NoObject BCE loss of objectness of boxes without objects
+	NoObject float32
+	// Classes BCE loss of classes of boxes which are assigned to objects
+	Classes float32
+}
+
+// Total Returns sum of components (value of cost function)
+func (l Losses) Total() float32 {
+	return l.Coordinates + l.Objectness + l.NoObject + l.Classes
+}
+
+// add Adds components of other losses
+func (l *Losses) add(other Losses) {
+	l.Coordinates += other.Coordinates
+	l.Objectness += other.Objectness
+	l.NoObject += other.NoObject
+	l.Classes += other.Classes
+}
+
+// scale Returns components multiplied by k (e.g. for averaging)
+func (l Losses) scale(k float32) Losses {
+	return Losses{
+		Coordinates: l.Coordinates * k,
+		Objectness:  l.Objectness * k,
+		NoObject:    l.NoObject * k,
+		Classes:     l.Classes * k,
+	}
+}
+
 type yoloTraining struct {
 	inputs  []float32
 	bboxes  []float32
 	scales  []float32
 	targets []float32
+	// truths Darknet annotations of image: [class, x, y, w, h, ...]
+	truths []float32
+	// active Entries of output which contribute to cost function (gradients of the other ones are zero)
+	active []bool
+	// losses Components of cost function of the last forward pass
+	losses Losses
 }
 
 // ActivateTrainingMode Activates training mode for yoloOP
@@ -31,7 +78,18 @@ func (op *yoloOp) DisableTrainingMode() {
 	op.trainMode = false
 }
 
+// Losses Returns components of cost function evaluated by the last forward pass in training mode
+func (op *yoloOp) Losses() Losses {
+	if op.training == nil {
+		return Losses{}
+	}
+	return op.training.losses
+}
+
 // SetTarget sets []float32 as desired target for yoloOP
+/*
+	Empty target means background-only image (negative sample): every predicted box gets no-object loss only.
+*/
 func (op *yoloOp) SetTarget(target []float32) {
 	preparedNumOfElements := op.gridSize * op.gridSize * len(op.masks) * (5 + op.numClasses)
 	if op.training == nil {
@@ -40,6 +98,7 @@ func (op *yoloOp) SetTarget(target []float32) {
 	}
 	op.training.scales = make([]float32, preparedNumOfElements)
 	op.training.targets = make([]float32, preparedNumOfElements)
+	op.training.truths = append([]float32{}, target...)
 	for i := range op.training.scales {
 		op.training.scales[i] = 1
 	}
@@ -47,6 +106,10 @@ func (op *yoloOp) SetTarget(target []float32) {
 	gridSizeF32 := float32(op.gridSize)
 	op.bestAnchors = getBestAnchorsF32(target, op.anchors, op.masks, op.dimensions, gridSizeF32)
 	for i := 0; i < len(op.bestAnchors); i++ {
+		// Best anchor of object belongs to another YOLO layer
+		if op.bestAnchors[i][0] == -1 {
+			continue
+		}
 		scale := (2 - target[i*5+3]*target[i*5+4])
 		giInt := op.bestAnchors[i][1]
 		gjInt := op.bestAnchors[i][2]
@@ -73,6 +136,10 @@ func (op *yoloOp) SetTarget(target []float32) {
 	}
 }
 
+// getBestAnchorsF32 Finds anchor with the best IoU among all anchors for every target object
+/*
+	Returns [position of anchor in masks (-1 if anchor is not selected by masks), cell x, cell y] for every object.
+*/
 func getBestAnchorsF32(target []float32, anchors []float32, masks []int, dims int, gridSize float32) [][]int {
 	bestAnchors := make([][]int, len(target)/5)
 	imgsize := float32(dims)
@@ -97,12 +164,20 @@ func getBestAnchorsF32(target []float32, anchors []float32, masks []int, dims in
 	return bestAnchors
 }
 
-func prepareTrainingOutputF32(input, yoloBoxes, target, scales []float32, bestAnchors [][]int, masks []int, numClasses, dims, gridSize int, ignoreTresh float32) []float32 {
+// prepareTrainingOutputF32 Evaluates loss of every entry of output and marks entries which contribute to cost function
+/*
+	Boxes which are not assigned to any object get no-object loss only (unless they overlap some object by more than
+	ignoreTresh), so background-only image (empty truths) contributes no-object loss of every box, as in Darknet.
+*/
+func prepareTrainingOutputF32(input, yoloBoxes, target, scales, truths []float32, bestAnchors [][]int, masks []int, numClasses, dims, gridSize int, ignoreTresh float32) ([]float32, []bool) {
 	yoloBBoxes := make([]float32, len(yoloBoxes))
-	bestIous := getBestIOUF32(yoloBoxes, target, numClasses, dims)
+	active := make([]bool, len(yoloBoxes))
+	// Predicted boxes are compared with ground truth boxes (not with prepared targets)
+	bestIous := getBestIOUF32(yoloBoxes, truths, numClasses, dims)
 	for i := 0; i < len(yoloBoxes); i = i + (5 + numClasses) {
 		if bestIous[i/(5+numClasses)][0] <= ignoreTresh {
 			yoloBBoxes[i+4] = bceLossF32(0, yoloBoxes[i+4])
+			active[i+4] = true
 		}
 	}
 	for i := 0; i < len(bestAnchors); i++ {
@@ -117,9 +192,37 @@ func prepareTrainingOutputF32(input, yoloBoxes, target, scales []float32, bestAn
 			for j := 0; j < numClasses+1; j++ {
 				yoloBBoxes[boxi+4+j] = bceLossF32(target[boxi+4+j], yoloBoxes[boxi+4+j])
 			}
+			for j := 0; j < 5+numClasses; j++ {
+				active[boxi+j] = true
+			}
+		}
+	}
+	return yoloBBoxes, active
+}
+
+// lossesF32 Splits output of prepareTrainingOutputF32() into components of cost function
+/*
+	Boxes which are assigned to objects have objectness target equal to 1 (see SetTarget()).
+*/
+func lossesF32(yoloBBoxes, target []float32, active []bool, numClasses int) Losses {
+	losses := Losses{}
+	for i := 0; i < len(yoloBBoxes); i = i + (5 + numClasses) {
+		if !active[i+4] {
+			continue
+		}
+		if target[i+4] != 1 {
+			losses.NoObject += yoloBBoxes[i+4]
+			continue
+		}
+		losses.Objectness += yoloBBoxes[i+4]
+		for j := 0; j < 4; j++ {
+			losses.Coordinates += yoloBBoxes[i+j]
+		}
+		for j := 5; j < 5+numClasses; j++ {
+			losses.Classes += yoloBBoxes[i+j]
 		}
 	}
-	return yoloBBoxes
+	return losses
 }
 
 func invsigmF32(target float32) float32 {
@@ -14,9 +14,15 @@ import (
 // YOLOv3 YOLOv3 architecture
 type YOLOv3 struct {
 	g                                 *gorgonia.ExprGraph
+	input                             *gorgonia.Node
 	classesNum, boxesPerCell, netSize int
 	out                               []*gorgonia.Node
+	layers                            []*layerN
 	layersInfo                        []string
+	weightsHeader                     []float32
+	unmapWeights                      func() error
+	// randomInit Parameters of random initialization (nil if weights have been read from file)
+	randomInit *randomInitOptions
 
 	LearningNodes []*gorgonia.Node
 	training      []YoloTrainer
@@ -35,7 +41,12 @@ func (net *YOLOv3) GetOutput() []*gorgonia.Node {
 }
 
 // NewYoloV3 Create new YOLO v3
-func NewYoloV3(g *gorgonia.ExprGraph, input *gorgonia.Node, classesNumber, boxesPerCell int, leakyCoef float64, cfgFile, weightsFile string) (*YOLOv3, error) {
+func NewYoloV3(g *gorgonia.ExprGraph, input *gorgonia.Node, classesNumber, boxesPerCell int, leakyCoef float64, cfgFile, weightsFile string, options ...ModelOption) (*YOLOv3, error) {
+	opts := modelOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+	netInput := input
 	shp := input.Shape()
 	if len(shp) < 4 {
 		return nil, fmt.Errorf("Input for tiny-YOLOv3 must contain 4 dimensions, but recieved %d)", len(shp))
@@ -53,21 +64,61 @@ func NewYoloV3(g *gorgonia.ExprGraph, input *gorgonia.Node, classesNumber, boxes
 		return nil, errors.Wrap(err, fmt.Sprintf("Network's width must be integer, got value: '%s'", netWidthStr))
 	}
 
-	weightsData, err := ParseWeights(weightsFile)
+	var weightsData []float32
+	var unmapWeights func() error
+	if opts.randomInit != nil {
+		if opts.mapWeights {
+			return nil, fmt.Errorf("Randomly initialized weights can't be memory-mapped")
+		}
+		weightsData, err = randomWeights(buildingBlocks, opts.randomInit.method, opts.randomInit.seed)
+		if err != nil {
+			return nil, errors.Wrap(err, "Can't initialize weights")
+		}
+	} else if opts.mapWeights {
+		if opts.halfWeights {
+			return nil, fmt.Errorf("Weights stored as float16 can't be memory-mapped")
+		}
+		weightsData, unmapWeights, err = mapWeights(weightsFile)
+	} else if opts.halfWeights {
+		weightsData, err = ParseWeightsF16(weightsFile)
+	} else {
+		weightsData, err = ParseWeights(weightsFile)
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "Can't read darknet weights")
 	}
+	// Mapping is owned by network on success (see Close()) and released on any error below
+	built := false
+	if unmapWeights != nil {
+		defer func() {
+			if !built {
+				unmapWeights()
+			}
+		}()
+	}
+	if len(weightsData) < weightsHeaderSize {
+		return nil, fmt.Errorf("Weights file should contain header of %d values, but got %d values only", weightsHeaderSize, len(weightsData))
+	}
+
+	// Mark start of forward pass for timings and profiling
+	if len(opts.layerObservers) > 0 {
+		input, err = hookNode(input, "layers_start", startHook(opts.layerObservers))
+		if err != nil {
+			return nil, errors.Wrap(err, "Can't prepare layers observation hook")
+		}
+	}
 
-	fmt.Println("Loading network...")
 	layers := []*layerN{}
 	outputFilters := []int{}
-	prevFilters := 3
+	prevFilters, err := InputChannels(netParams)
+	if err != nil {
+		return nil, err
+	}
 
 	networkNodes := []*gorgonia.Node{}
 
 	blocks := buildingBlocks[1:]
-	lastIdx := 5 // Skip first 5 values (header of weights file)
-	epsilon := float32(0.000001)
+	lastIdx := weightsHeaderSize // Skip header of weights file
 
 	yoloNodes := []*gorgonia.Node{}
 	learningNodes := []*gorgonia.Node{}
@@ -161,9 +212,15 @@ func NewYoloV3(g *gorgonia.ExprGraph, input *gorgonia.Node, classesNumber, boxes
 					lastIdx += nk
 
 					// Denormalize weights
+					// (no-op writes are skipped, so memory-mapped pages of already folded weights stay shared)
 					for s := 0; s < shp[0]; s++ {
-						scale := gammas[s] / math32.Sqrt(vars[s]+epsilon)
-						biases[s] = biases[s] - means[s]*scale
+						scale := gammas[s] / math32.Sqrt(vars[s]+batchNormEpsilon)
+						if means[s] != 0 {
+							biases[s] = biases[s] - means[s]*scale
+						}
+						if scale == 1 {
+							continue
+						}
 						isize := shp[1] * shp[2] * shp[3]
 						for j := 0; j < isize; j++ {
 							kernels[isize*s+j] *= scale
@@ -185,6 +242,10 @@ func NewYoloV3(g *gorgonia.ExprGraph, input *gorgonia.Node, classesNumber, boxes
 				ll.convNode = convNode
 				ll.biases = biases
 				ll.layerIndex = i
+				ll.calibrator = opts.calibrator
+				if opts.quantization != nil {
+					ll.quantization = opts.quantization.layers[i]
+				}
 
 				var l layerN = ll
 				convBlock, err := l.ToNode(g, input)
@@ -195,7 +256,9 @@ func NewYoloV3(g *gorgonia.ExprGraph, input *gorgonia.Node, classesNumber, boxes
 				input = convBlock
 
 				layers = append(layers, &l)
-				learningNodes = append(learningNodes, ll.convNode)
+				if ll.quantization == nil {
+					learningNodes = append(learningNodes, ll.convNode)
+				}
 
 				filtersIdx = filters
 				break
@@ -330,13 +393,17 @@ func NewYoloV3(g *gorgonia.ExprGraph, input *gorgonia.Node, classesNumber, boxes
 					anchorsPairs = append(anchorsPairs, [2]int{anchors[a], anchors[a+1]})
 				}
 				selectedAnchors := [][2]int{}
+				wrongMask := false
 				for m := range masks {
+					if masks[m] < 0 || masks[m] >= len(anchorsPairs) {
+						wrongMask = true
+						break
+					}
 					selectedAnchors = append(selectedAnchors, anchorsPairs[masks[m]])
 				}
-				flatten := []int{}
-				for a := range selectedAnchors {
-					flatten = append(flatten, selectedAnchors[a][0])
-					flatten = append(flatten, selectedAnchors[a][1])
+				if wrongMask {
+					fmt.Printf("Each element of 'mask' parameter for yolo layer should be an index of anchor (there are %d anchors)\n", len(anchorsPairs))
+					continue
 				}
 
 				ignoreThreshStr, ok := block["ignore_thresh"]
@@ -350,7 +417,7 @@ func NewYoloV3(g *gorgonia.ExprGraph, input *gorgonia.Node, classesNumber, boxes
 				yoloL := yoloLayer{
 					masks:          masks,
 					anchors:        selectedAnchors,
-					flattenAnchors: flatten,
+					flattenAnchors: anchors,
 					inputSize:      shp[2],
 					classesNum:     classesNumber,
 					ignoreThresh:   float32(ignoreThresh64),
@@ -440,6 +507,18 @@ func NewYoloV3(g *gorgonia.ExprGraph, input *gorgonia.Node, classesNumber, boxes
 				break
 			}
 		}
+		// Mark end of layer for timings and profiling
+		if len(opts.layerObservers) > 0 && len(networkNodes) == i+1 {
+			observedNode, err := hookNode(networkNodes[i], fmt.Sprintf("layer_%d", i), layerHook(opts.layerObservers, i, fmt.Sprintf("%v", *layers[len(layers)-1])))
+			if err != nil {
+				return nil, errors.Wrap(err, "Can't prepare layer observation hook")
+			}
+			networkNodes[i] = observedNode
+			input = observedNode
+			if layerType == "yolo" {
+				yoloNodes[len(yoloNodes)-1] = observedNode
+			}
+		}
 		prevFilters = filtersIdx
 		outputFilters = append(outputFilters, filtersIdx)
 	}
@@ -451,18 +530,45 @@ func NewYoloV3(g *gorgonia.ExprGraph, input *gorgonia.Node, classesNumber, boxes
 	}
 
 	model := &YOLOv3{
+		g:             g,
+		input:         netInput,
 		classesNum:    classesNumber,
 		boxesPerCell:  boxesPerCell,
 		netSize:       netWidth,
 		out:           yoloNodes,
+		layers:        layers,
 		layersInfo:    linfo,
+		weightsHeader: append([]float32{}, weightsData[:weightsHeaderSize]...),
+		unmapWeights:  unmapWeights,
+		randomInit:    opts.randomInit,
 		LearningNodes: learningNodes,
 		training:      yoloTrainers,
 	}
-
+	built = true
 	return model, nil
 }
 
+// Close Releases memory-mapped weights (see WithMappedWeights()). Network can't be used after that
+func (net *YOLOv3) Close() error {
+	if net.unmapWeights == nil {
+		return nil
+	}
+	err := net.unmapWeights()
+	net.unmapWeights = nil
+	return err
+}
+
+// convLayers Returns convolutional layers of network in order of appearance
+func (net *YOLOv3) convLayers() []*convLayer {
+	convs := []*convLayer{}
+	for i := range net.layers {
+		if conv, ok := (*net.layers[i]).(*convLayer); ok {
+			convs = append(convs, conv)
+		}
+	}
+	return convs
+}
+
 // ActivateTrainingMode Activates training mode for unexported yoloOP
 func (net *YOLOv3) ActivateTrainingMode() error {
 	if len(net.training) == 0 {
@@ -486,10 +592,16 @@ func (net *YOLOv3) DisableTrainingMode() error {
 }
 
 // SetTarget Set desired target for net's output (for training mode)
+/*
+	target - darknet annotations of image (see ParseLabels()); empty (or nil) target means image without objects.
+*/
 func (net *YOLOv3) SetTarget(target []float32) error {
 	if len(net.training) == 0 {
 		return fmt.Errorf("Model has not any YOLO layers")
 	}
+	if len(target)%5 != 0 {
+		return fmt.Errorf("Number of values in target should be divided exactly by 5, but got %d", len(target))
+	}
 	for i := range net.training {
 		net.training[i].SetTarget(target)
 	}